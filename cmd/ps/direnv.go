@@ -0,0 +1,82 @@
+package ps
+
+import (
+	"bear_cli/internal/ps"
+	"bear_cli/models"
+	"bear_cli/pkg/prompt"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type direnvOptions struct {
+	Scope string
+}
+
+func direnvCmd() *cobra.Command {
+	opts := &direnvOptions{}
+
+	cmd := &cobra.Command{
+		Use:   string(models.PsDirenv),
+		Short: models.CommandDescriptions[models.PsDirenv],
+		Long: models.CommandDescriptions[models.PsDirenv] + `.
+
+Add this line to .envrc:
+
+    eval "$(bear ps direnv)"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scope, err := models.ParseCredentialScope(opts.Scope)
+			if err != nil {
+				return err
+			}
+
+			profileName, err := ps.ResolveProfile(profile)
+			if err != nil {
+				return err
+			}
+
+			files, err := ps.DirenvWatchFiles(profileName, profile == "")
+			if err != nil {
+				return err
+			}
+			for _, f := range files {
+				fmt.Printf("watch_file %s\n", prompt.QuoteShell(f))
+			}
+
+			// Report problems through direnv instead of failing, so the watches above still reload the environment once fixed
+			cred, err := ps.LoadSandboxCredential(profileName)
+			if err != nil {
+				fmt.Printf("log_error %s\n", prompt.QuoteShell("bear: "+err.Error()))
+				return nil
+			}
+			if cred.IsExpired() {
+				expiredErr := &ps.SandboxExpiredError{Profile: profileName, ExpiredAt: cred.ExpiresAt()}
+				fmt.Printf("log_error %s\n", prompt.QuoteShell("bear: "+expiredErr.Error()))
+				return nil
+			}
+
+			env := cred.ToScopedEnvMap(scope)
+			for _, k := range cred.EnvKeys() {
+				if v, ok := env[k]; ok {
+					fmt.Printf("export %s=%s\n", k, prompt.QuoteShell(v))
+				}
+			}
+
+			status := fmt.Sprintf("bear: profile %s (%s)", profileName, cred.Provider())
+			if expiresAt := cred.ExpiresAt(); expiresAt.IsZero() {
+				status += ", expiry unknown"
+			} else {
+				status += fmt.Sprintf(", expires in %s", time.Until(expiresAt).Truncate(time.Minute))
+			}
+			fmt.Printf("log_status %s\n", prompt.QuoteShell(status))
+
+			return nil
+		},
+	}
+
+	addScopeFlag(cmd, &opts.Scope)
+
+	return cmd
+}
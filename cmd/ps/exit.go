@@ -0,0 +1,39 @@
+package ps
+
+import (
+	"bear_cli/internal/armapi"
+	"bear_cli/internal/ps"
+	"errors"
+)
+
+// Exit codes returned by `bear ps` so scripts can tell failures apart.
+const (
+	ExitGeneric       = 1
+	ExitNoHTMLSource  = 3
+	ExitHTMLSource    = 4
+	ExitMissingField  = 5
+	ExitTokenExchange = 6
+	ExitExpired       = 7
+)
+
+func ExitCode(err error) int {
+	var sourceErr *ps.HTMLSourceError
+	var missingErr *ps.MissingFieldError
+	var tokenErr *armapi.TokenExchangeError
+	var expiredErr *ps.SandboxExpiredError
+
+	switch {
+	case errors.Is(err, ps.ErrNoHTMLSource):
+		return ExitNoHTMLSource
+	case errors.As(err, &sourceErr):
+		return ExitHTMLSource
+	case errors.As(err, &missingErr):
+		return ExitMissingField
+	case errors.As(err, &tokenErr):
+		return ExitTokenExchange
+	case errors.As(err, &expiredErr):
+		return ExitExpired
+	default:
+		return ExitGeneric
+	}
+}
@@ -0,0 +1,62 @@
+package ps
+
+import (
+	"bear_cli/internal/armapi"
+	"bear_cli/internal/ps"
+	"bear_cli/internal/secretstore"
+	"bear_cli/models"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"generic", errors.New("boom"), ExitGeneric},
+		{"no html source", ps.ErrNoHTMLSource, ExitNoHTMLSource},
+		{"html source", &ps.HTMLSourceError{Source: "clipboard", Err: errors.New("empty")}, ExitHTMLSource},
+		{"missing field", &ps.MissingFieldError{Provider: "aws", Fields: []string{"AccessKeyId"}}, ExitMissingField},
+		{"token exchange", &armapi.TokenExchangeError{Tenant: "tenant", StatusCode: 401}, ExitTokenExchange},
+		{"expired", &ps.SandboxExpiredError{Profile: "default"}, ExitExpired},
+		{"wrapped", fmt.Errorf("create-cred: %w", &ps.MissingFieldError{Provider: "azure"}), ExitMissingField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAWSCredentialProcessExpired(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, err := secretstore.New(secretstore.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred := &models.PsAwsCredential{
+		AWSCredential:   models.AWSCredential{AccessKeyId: "AKIAEXAMPLE", SecretAccessKey: "secret"},
+		SandboxLifetime: models.SandboxLifetime{Expiration: time.Now().Add(-time.Minute)},
+	}
+	if err := ps.SaveSandboxCredential(cred, "aws-lab", store); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := awsCredentialProcessCmd()
+	cmd.SetArgs([]string{})
+	cmd.SetErr(io.Discard)
+	profile = "aws-lab"
+	t.Cleanup(func() { profile = "" })
+
+	if got := ExitCode(cmd.Execute()); got != 7 {
+		t.Errorf("exit code = %d, want 7", got)
+	}
+}
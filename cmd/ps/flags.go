@@ -0,0 +1,105 @@
+package ps
+
+import (
+	"bear_cli/internal/awscli"
+	"bear_cli/internal/browser"
+	"bear_cli/internal/ps"
+	"bear_cli/internal/secretstore"
+	"bear_cli/models"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func completeValues(values []string) cobra.CompletionFunc {
+	return cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp)
+}
+
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	profiles, err := ps.ListProfiles()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return profiles, cobra.ShellCompDirectiveNoFileComp
+}
+
+func addScopeFlag(cmd *cobra.Command, scope *string) {
+	cmd.Flags().StringVarP(scope, "scope", "s", string(models.ScopeFull), "Credential scope: "+strings.Join(models.CredentialScopeNames(), ", "))
+	cmd.RegisterFlagCompletionFunc("scope", completeValues(models.CredentialScopeNames()))
+}
+
+func addCloudProviderFlag(cmd *cobra.Command, cloudProvider *string) {
+	cmd.Flags().StringVarP(cloudProvider, "cloud-provider", "", models.ProviderAzure, "Cloud provider: "+strings.Join(models.CloudProviderNames(), ", "))
+	cmd.RegisterFlagCompletionFunc("cloud-provider", completeValues(models.CloudProviderNames()))
+}
+
+func addStoreFlag(cmd *cobra.Command, store *string) {
+	cmd.Flags().StringVarP(store, "store", "", "", "Credential store: "+strings.Join(secretstore.Names(), ", ")+" (defaults to the config, then file)")
+	cmd.RegisterFlagCompletionFunc("store", completeValues(secretstore.Names()))
+}
+
+func addAWSProfileFlags(cmd *cobra.Command, write *bool, awsProfile *string, credentialProcess *bool) {
+	cmd.Flags().BoolVarP(write, "write-aws-profile", "", false, "Also write the AWS credential into ~/.aws/credentials and ~/.aws/config")
+	cmd.Flags().StringVarP(awsProfile, "aws-profile", "", awscli.DefaultProfile, "Name of the AWS profile written by --write-aws-profile")
+	cmd.Flags().BoolVarP(credentialProcess, "aws-credential-process", "", false, "Make --write-aws-profile point credential_process at bear instead of writing the keys")
+}
+
+func addAzureCLIFlags(cmd *cobra.Command, write *bool, dir *string) {
+	cmd.Flags().BoolVarP(write, "write-az-config", "", false, "Also log an isolated Azure CLI config directory into the sandbox service principal")
+	cmd.Flags().StringVarP(dir, "az-config-dir", "", "", "AZURE_CONFIG_DIR written by --write-az-config (defaults to ~/.config/bear/ps/azure/<profile>)")
+	cmd.MarkFlagDirname("az-config-dir")
+}
+
+// Flags of the commands that log in to the cloud console through a browser.
+type browserOptions struct {
+	BrowserMode    string
+	RemoteURL      string
+	ChromePath     string
+	Timeout        time.Duration
+	NonInteractive bool
+	// Keep cookies in the bear profile's own Chrome profile instead of an incognito window
+	PersistentBrowser bool
+	// Print the AWS console sign-in URL instead of opening a browser
+	PrintURL bool
+}
+
+var errPrintURLNotAWS = errors.New("--print-url is only supported for AWS sandboxes")
+
+func addBrowserFlags(cmd *cobra.Command, opts *browserOptions) {
+	cmd.Flags().StringVarP(&opts.BrowserMode, "browser-mode", "", string(browser.ModeLocal), "Browser used to log in: "+strings.Join(browser.ModeNames(), ", "))
+	cmd.RegisterFlagCompletionFunc("browser-mode", completeValues(browser.ModeNames()))
+	cmd.Flags().StringVarP(&opts.RemoteURL, "remote-debugging-url", "", "", "DevTools endpoint of the Chrome used by --browser-mode=remote (e.g. http://127.0.0.1:9222)")
+	cmd.Flags().StringVarP(&opts.ChromePath, "chrome-path", "", "", "Chrome binary to launch instead of the one found on the PATH")
+	cmd.Flags().DurationVarP(&opts.Timeout, "browser-timeout", "", browser.DefaultTimeout, "Give up when the login steps take longer than this")
+	cmd.Flags().BoolVarP(&opts.NonInteractive, "non-interactive", "", false, "Return once logged in instead of waiting for ENTER; a local browser closes with bear, so add --persistent-browser to keep the session")
+	cmd.Flags().BoolVarP(&opts.PersistentBrowser, "persistent-browser", "", false, "Reuse a Chrome profile kept for this bear profile instead of an incognito window")
+	cmd.Flags().BoolVarP(&opts.PrintURL, "print-url", "", false, "Print the AWS console sign-in URL instead of opening a browser")
+}
+
+func (o *browserOptions) options(profileName string) (browser.Options, error) {
+	mode, err := browser.ParseMode(o.BrowserMode)
+	if err != nil {
+		return browser.Options{}, err
+	}
+
+	opts := browser.Options{
+		Mode:           mode,
+		RemoteURL:      o.RemoteURL,
+		ChromePath:     o.ChromePath,
+		Timeout:        o.Timeout,
+		NonInteractive: o.NonInteractive,
+	}
+
+	if o.PersistentBrowser {
+		if mode == browser.ModeRemote {
+			return opts, errors.New("--persistent-browser cannot be used with --browser-mode=remote")
+		}
+		if opts.UserDataDir, err = ps.BrowserProfileDir(profileName); err != nil {
+			return opts, err
+		}
+	}
+
+	return opts, nil
+}
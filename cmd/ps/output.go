@@ -0,0 +1,148 @@
+package ps
+
+import (
+	"bear_cli/internal/outfile"
+	"bear_cli/models"
+	"bear_cli/pkg/prompt"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Flags shared by every command that prints a credential through prompt.FprintStdOut.
+type outputOptions struct {
+	Output  string
+	Sort    string
+	Columns []string
+	Mask    bool
+	Reveal  bool
+	OutFile string
+	Append  bool
+	Merge   bool
+	// Metadata of the k8s-secret format
+	SecretName      string
+	SecretNamespace string
+	SecretLabels    map[string]string
+
+	format models.StdOutFormat
+	sortBy models.SortOrder
+}
+
+func addOutputFlags(cmd *cobra.Command, opts *outputOptions, defaultOutput string) {
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", defaultOutput, "Output format: "+strings.Join(models.StdOutFormatNames(), ", "))
+	cmd.RegisterFlagCompletionFunc("output", completeValues(models.StdOutFormatNames()))
+	cmd.Flags().StringVarP(&opts.Sort, "sort", "", string(models.SortBySchema), "Order of the printed variables: "+strings.Join(models.SortOrderNames(), ", "))
+	cmd.RegisterFlagCompletionFunc("sort", completeValues(models.SortOrderNames()))
+	cmd.Flags().StringSliceVarP(&opts.Columns, "columns", "", nil, "Print only these variables, in this order (e.g. AWS_ACCESS_KEY_ID,AWS_REGION)")
+	cmd.Flags().BoolVarP(&opts.Mask, "mask", "", false, "Redact secrets in every output format")
+	cmd.Flags().BoolVarP(&opts.Reveal, "reveal", "", false, "Print secrets in clear text, even in table and JSON output")
+	cmd.MarkFlagsMutuallyExclusive("mask", "reveal")
+	cmd.Flags().StringVarP(&opts.OutFile, "out-file", "", "", "Write the output to this file (mode 0600) instead of stdout")
+	cmd.Flags().BoolVarP(&opts.Append, "append", "", false, "Append to --out-file instead of replacing it (env and dotenv only)")
+	cmd.Flags().BoolVarP(&opts.Merge, "merge", "", false, "Update the variables in --out-file and keep its other lines (env and dotenv only)")
+	cmd.MarkFlagsMutuallyExclusive("append", "merge")
+	cmd.Flags().StringVarP(&opts.SecretName, "k8s-name", "", prompt.DefaultSecretName, "Name of the Secret printed by the k8s-secret format")
+	cmd.Flags().StringVarP(&opts.SecretNamespace, "k8s-namespace", "", "", "Namespace of the Secret printed by the k8s-secret format")
+	cmd.Flags().StringToStringVarP(&opts.SecretLabels, "k8s-label", "", nil, "Label of the Secret printed by the k8s-secret format (key=value, repeatable)")
+}
+
+// Secrets are redacted in the formats meant for reading (table, JSON) unless revealed,
+// and kept in the formats meant for shells and in --out-file unless masking is asked for.
+func (o *outputOptions) masked() bool {
+	switch {
+	case o.Mask:
+		return true
+	case o.Reveal:
+		return false
+	default:
+		return o.OutFile == "" && (o.format == models.TABLE || o.format == models.JSON)
+	}
+}
+
+// Parses the flags up front so a bad value fails before any sandbox is touched.
+func (o *outputOptions) parse() error {
+	var err error
+	if o.format, err = models.ParseStdOutFormat(o.Output); err != nil {
+		return err
+	}
+	if o.sortBy, err = models.ParseSortOrder(o.Sort); err != nil {
+		return err
+	}
+
+	if o.OutFile != "" {
+		if err := outfile.Check(o.OutFile); err != nil {
+			return err
+		}
+	}
+
+	if o.format == models.GITHUB_ACTIONS && o.OutFile != "" {
+		return errors.New("the github format writes to $GITHUB_ENV and cannot be used with --out-file")
+	}
+
+	if o.Append || o.Merge {
+		if o.OutFile == "" {
+			return errors.New("--append and --merge need --out-file")
+		}
+		if o.format != models.LINUX_ENV_VAR && o.format != models.DOTENV {
+			return fmt.Errorf("--append and --merge only work with the env and dotenv formats, not %s", o.Output)
+		}
+	}
+	return nil
+}
+
+func (o *outputOptions) print(cred models.SandboxCredential, data map[string]string) error {
+	printOpts := prompt.PrintOptions{
+		Order:   cred.EnvKeys(),
+		SortBy:  o.sortBy,
+		Columns: o.Columns,
+		Secrets: cred.SecretEnvKeys(),
+		KubernetesSecret: prompt.KubernetesSecretOptions{
+			Name:      o.SecretName,
+			Namespace: o.SecretNamespace,
+			Labels:    o.SecretLabels,
+		},
+	}
+	if o.masked() {
+		printOpts.Masked = cred.SecretEnvKeys()
+	}
+
+	if o.format == models.GITHUB_ACTIONS {
+		path := os.Getenv("GITHUB_ENV")
+		if path == "" {
+			return errors.New("GITHUB_ENV is not set: the github format only works inside a GitHub Actions step")
+		}
+		env, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return err
+		}
+		defer env.Close()
+		printOpts.GitHubEnv = env
+	}
+
+	if o.OutFile == "" {
+		return prompt.FprintStdOut(os.Stdout, data, o.format, printOpts)
+	}
+
+	var b bytes.Buffer
+	if err := prompt.FprintStdOut(&b, data, o.format, printOpts); err != nil {
+		return err
+	}
+
+	mode := outfile.Overwrite
+	switch {
+	case o.Append:
+		mode = outfile.Append
+	case o.Merge:
+		mode = outfile.Merge
+	}
+	if err := outfile.Write(o.OutFile, b.Bytes(), mode); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote credential to %s\n", o.OutFile)
+	return nil
+}
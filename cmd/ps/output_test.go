@@ -0,0 +1,87 @@
+package ps
+
+import (
+	"bear_cli/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestOutputOptionsMasked(t *testing.T) {
+	cred := &models.PsAwsCredential{
+		AWSCredential: models.AWSCredential{AccessKeyId: "AKIAEXAMPLE", SecretAccessKey: "s3cr3t-access-key", Region: "us-east-1"},
+		Password:      "s3cr3t-password",
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantMasked bool
+		wantErr    bool
+	}{
+		{name: "table by default", wantMasked: true},
+		{name: "json by default", args: []string{"-o", "json"}, wantMasked: true},
+		{name: "env by default", args: []string{"-o", "env"}},
+		{name: "dotenv by default", args: []string{"-o", "dotenv"}},
+		{name: "table revealed", args: []string{"--reveal"}},
+		{name: "json revealed", args: []string{"-o", "json", "--reveal"}},
+		{name: "env masked", args: []string{"-o", "env", "--mask"}, wantMasked: true},
+		{name: "table masked", args: []string{"--mask"}, wantMasked: true},
+		{name: "json to a file", args: []string{"-o", "json", "--out-file", "cred.json"}},
+		{name: "json to a file masked", args: []string{"-o", "json", "--out-file", "cred.json", "--mask"}, wantMasked: true},
+		{name: "dotenv to a file masked", args: []string{"-o", "dotenv", "--out-file", "cred.env", "--mask"}, wantMasked: true},
+		{name: "mask and reveal together", args: []string{"--mask", "--reveal"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var opts outputOptions
+			cmd := &cobra.Command{}
+			addOutputFlags(cmd, &opts, "table")
+
+			err := cmd.ParseFlags(tt.args)
+			if err == nil {
+				err = cmd.ValidateFlagGroups()
+			}
+			if opts.OutFile != "" {
+				opts.OutFile = filepath.Join(dir, opts.OutFile)
+			}
+			if err == nil {
+				err = opts.parse()
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got := opts.masked(); got != tt.wantMasked {
+				t.Errorf("masked() = %t, want %t", got, tt.wantMasked)
+			}
+			if opts.OutFile == "" {
+				return
+			}
+
+			if err := opts.print(cred, cred.ToEnvMap()); err != nil {
+				t.Fatal(err)
+			}
+			written, err := os.ReadFile(opts.OutFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, secret := range []string{cred.SecretAccessKey, cred.Password} {
+				if strings.Contains(string(written), secret) == tt.wantMasked {
+					t.Errorf("secret %q written = %t, want %t:\n%s", secret, !tt.wantMasked, !tt.wantMasked, written)
+				}
+			}
+			if !strings.Contains(string(written), cred.AccessKeyId) {
+				t.Errorf("access key id missing from the file:\n%s", written)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package ps
+
+import (
+	"bear_cli/internal/ps"
+	"bear_cli/models"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func profilesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   string(models.PsProfiles),
+		Short: models.CommandDescriptions[models.PsProfiles],
+	}
+
+	cmd.AddCommand(listProfilesCmd())
+	cmd.AddCommand(showProfileCmd())
+	cmd.AddCommand(deleteProfileCmd())
+	cmd.AddCommand(useProfileCmd())
+
+	return cmd
+}
+
+func listProfilesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   string(models.PsProfilesList),
+		Short: models.CommandDescriptions[models.PsProfilesList],
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profiles, err := ps.ListProfiles()
+			if err != nil {
+				return err
+			}
+
+			current, err := ps.CurrentProfile()
+			if err != nil {
+				return err
+			}
+
+			for _, p := range profiles {
+				marker := " "
+				if p == current {
+					marker = "*"
+				}
+				fmt.Printf("%s %s\n", marker, p)
+			}
+			return nil
+		},
+	}
+}
+
+type showProfileOptions struct {
+	outputOptions
+}
+
+func showProfileCmd() *cobra.Command {
+	opts := &showProfileOptions{}
+
+	cmd := &cobra.Command{
+		Use:               string(models.PsProfilesShow) + " [profile]",
+		Short:             models.CommandDescriptions[models.PsProfilesShow],
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.parse(); err != nil {
+				return err
+			}
+
+			name := profile
+			if len(args) > 0 {
+				name = args[0]
+			}
+
+			name, err := ps.ResolveProfile(name)
+			if err != nil {
+				return err
+			}
+
+			cred, err := ps.LoadSandboxCredential(name)
+			if err != nil {
+				return err
+			}
+
+			// On stderr so json, yaml and env output stays parseable
+			fmt.Fprintf(os.Stderr, "Profile: %s\nProvider: %s\n\n", name, cred.Provider())
+			if err := opts.print(cred, cred.ToEnvMap()); err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	addOutputFlags(cmd, &opts.outputOptions, "table")
+
+	return cmd
+}
+
+func deleteProfileCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               string(models.PsProfilesDelete) + " <profile>",
+		Short:             models.CommandDescriptions[models.PsProfilesDelete],
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ps.DeleteProfile(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted profile %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+func useProfileCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:               string(models.PsProfilesUse) + " <profile>",
+		Short:             models.CommandDescriptions[models.PsProfilesUse],
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ps.UseProfile(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Now using profile %s\n", args[0])
+			return nil
+		},
+	}
+}
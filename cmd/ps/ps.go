@@ -1,27 +1,70 @@
 package ps
 
 import (
+	"bear_cli/internal/awscli"
 	"bear_cli/internal/browser"
 	"bear_cli/internal/ps"
 	"bear_cli/models"
-	"bear_cli/pkg/prompt"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
-	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+var profile string
+
 var PsCmd = &cobra.Command{
 	Use:   string(models.Ps),
 	Short: "Provide PluralSight's credential management capabilities.",
-	Long:  "Extract, manage, and utilize PluralSight's sandbox credentials for AWS and Azure with ease.",
+	Long:  "Extract, manage, and utilize PluralSight's sandbox credentials for AWS, Azure and Google Cloud with ease.",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Wipes browser profiles whose sandbox is gone, whatever the purge settings, on a best-effort basis that only warns on failure
+		wiped, err := ps.PurgeStaleBrowserProfiles()
+		for _, p := range wiped {
+			fmt.Fprintf(os.Stderr, "Wiped browser profile of %s, whose sandbox is gone\n", p)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to wipe stale browser profiles: %v\n", err)
+		}
+
+		config, err := ps.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping purge of expired credentials: %v\n", err)
+			return nil
+		}
+		if !config.AutoPurgeExpired {
+			return nil
+		}
+
+		purged, err := ps.PurgeExpiredCredentials()
+		for _, p := range purged {
+			fmt.Fprintf(os.Stderr, "Purged expired credential for profile %s\n", p)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to purge expired credentials: %v\n", err)
+		}
+		return nil
+	},
 }
 
 func init() {
+	PsCmd.PersistentFlags().StringVar(&profile, "profile", "", "Credential profile to use (defaults to the current profile)")
+	PsCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+
 	PsCmd.AddCommand(createCredentialCmd())
 	PsCmd.AddCommand(getCredentialCmd())
 	PsCmd.AddCommand(initCredentialCmd())
 	PsCmd.AddCommand(loginCmd())
+	PsCmd.AddCommand(execCmd())
+	PsCmd.AddCommand(profilesCmd())
+	PsCmd.AddCommand(statusCmd())
+	PsCmd.AddCommand(purgeCredentialCmd())
+	PsCmd.AddCommand(rulesCmd())
+	PsCmd.AddCommand(awsCredentialProcessCmd())
+	PsCmd.AddCommand(direnvCmd())
 }
 
 type PsCreateCredentialOptions struct {
@@ -29,8 +72,18 @@ type PsCreateCredentialOptions struct {
 	FilePath      string
 	CloudProvider string
 	Login         bool
-	Output        string
-	Scope         string
+	browserOptions
+	outputOptions
+	Scope           string
+	TTL             time.Duration
+	Store           string
+	AllowPartial    bool
+	WriteAWSProfile bool
+	AWSProfile      string
+	// Write a credential_process entry instead of the keys
+	AWSCredentialProcess bool
+	WriteAzureCLIConfig  bool
+	AzureConfigDir       string
 }
 
 func createCredentialCmd() *cobra.Command {
@@ -40,20 +93,94 @@ func createCredentialCmd() *cobra.Command {
 		Use:   string(models.PsCreateCredential),
 		Short: models.CommandDescriptions[models.PsCreateCredential],
 		RunE: func(cmd *cobra.Command, args []string) error {
-			format := models.ParseStdOutFormat(opts.Output)
-			scope := models.ParseCredentialScope(opts.Scope)
+			cloudProvider, err := models.ParseCloudProvider(opts.CloudProvider)
+			if err != nil {
+				return err
+			}
+			if opts.PrintURL && cloudProvider != models.AWS {
+				return errPrintURLNotAWS
+			}
+			// Fail before the sandbox page is read, not after the credential is saved
+			if opts.WriteAWSProfile {
+				if err := awscli.ValidateProfileName(opts.AWSProfile); err != nil {
+					return err
+				}
+			}
+			if err := opts.parse(); err != nil {
+				return err
+			}
+			scope, err := models.ParseCredentialScope(opts.Scope)
+			if err != nil {
+				return err
+			}
+
+			profileName, err := ps.ResolveProfile(profile)
+			if err != nil {
+				return err
+			}
+			browserOpts, err := opts.options(profileName)
+			if err != nil {
+				return err
+			}
+
+			store, err := ps.ResolveStore(opts.Store)
+			if err != nil {
+				return err
+			}
 
-			if strings.EqualFold(opts.CloudProvider, "azure") {
-				cred := ps.CreatePsAzureCredential(opts.UseClipboard, opts.FilePath)
-				prompt.PrintStdOut(cred.ToScopedEnvMap(scope), format)
+			createOpts := ps.CreateCredentialOptions{
+				UseClipboard: opts.UseClipboard,
+				HTMLPath:     opts.FilePath,
+				Profile:      profileName,
+				TTL:          opts.TTL,
+				Store:        store,
+				AllowPartial: opts.AllowPartial,
+				Report:       os.Stderr,
+			}
+
+			switch cloudProvider {
+			case models.Azure:
+				cred, err := ps.CreatePsAzureCredential(createOpts)
+				if err != nil {
+					return err
+				}
+				if opts.WriteAzureCLIConfig {
+					if err := writeAzureCLIConfig(profileName, &cred, opts.AzureConfigDir); err != nil {
+						return err
+					}
+				}
+				if err := opts.print(&cred, cred.ToScopedEnvMap(scope)); err != nil {
+					return err
+				}
 				if opts.Login {
-					browser.LoginInBrowser(cred.User, cred.Password, browser.AzurePortal, cred.SandboxURL)
+					return login(&cred, browserOpts, false)
+				}
+			case models.GCP:
+				cred, err := ps.CreatePsGCPCredential(createOpts)
+				if err != nil {
+					return err
+				}
+				if err := opts.print(&cred, cred.ToScopedEnvMap(scope)); err != nil {
+					return err
 				}
-			} else {
-				cred := ps.CreatePsAWSCredential(opts.UseClipboard, opts.FilePath)
-				prompt.PrintStdOut(cred.ToScopedEnvMap(scope), format)
 				if opts.Login {
-					browser.LoginInBrowser(cred.User, cred.Password, browser.AWSConsole, cred.SandboxURL)
+					return login(&cred, browserOpts, false)
+				}
+			case models.AWS:
+				cred, err := ps.CreatePsAWSCredential(createOpts)
+				if err != nil {
+					return err
+				}
+				if opts.WriteAWSProfile {
+					if err := ps.WriteAWSSharedProfile(profileName, &cred, opts.AWSProfile, opts.AWSCredentialProcess); err != nil {
+						return err
+					}
+				}
+				if err := opts.print(&cred, cred.ToScopedEnvMap(scope)); err != nil {
+					return err
+				}
+				if opts.Login || opts.PrintURL {
+					return login(&cred, browserOpts, opts.PrintURL)
 				}
 			}
 
@@ -62,11 +189,17 @@ func createCredentialCmd() *cobra.Command {
 	}
 
 	cmd.Flags().BoolVarP(&opts.UseClipboard, "clipboard", "", true, "Read HTML from clipboard")
-	cmd.Flags().StringVarP(&opts.FilePath, "html-path", "", "", "Path of HTML file")
-	cmd.Flags().StringVarP(&opts.CloudProvider, "cloud-provider", "", "Azure", "Cloud provider (aws, azure)")
+	cmd.Flags().StringVarP(&opts.FilePath, "html-path", "", "", "Path of a saved HTML, MHTML or HAR file of the sandbox page (\"-\" for stdin)")
+	addCloudProviderFlag(cmd, &opts.CloudProvider)
 	cmd.Flags().BoolVarP(&opts.Login, "login", "", false, "Will login or not")
-	cmd.Flags().StringVarP(&opts.Output, "output", "o", "env", "Output format: env, json, table")
-	cmd.Flags().StringVarP(&opts.Scope, "scope", "s", "full", "Credential scope: full, terraform")
+	addBrowserFlags(cmd, &opts.browserOptions)
+	addOutputFlags(cmd, &opts.outputOptions, "env")
+	addScopeFlag(cmd, &opts.Scope)
+	cmd.Flags().DurationVarP(&opts.TTL, "ttl", "", 0, "Sandbox lifetime (e.g. 4h), overrides the time remaining found on the page")
+	addStoreFlag(cmd, &opts.Store)
+	cmd.Flags().BoolVarP(&opts.AllowPartial, "allow-partial", "", false, "Save the credential even if required fields are missing or invalid")
+	addAWSProfileFlags(cmd, &opts.WriteAWSProfile, &opts.AWSProfile, &opts.AWSCredentialProcess)
+	addAzureCLIFlags(cmd, &opts.WriteAzureCLIConfig, &opts.AzureConfigDir)
 
 	return cmd
 }
@@ -75,6 +208,15 @@ type PluralSightOptions struct {
 	UseClipboard bool
 	HTMLPath     string
 	Login        bool
+	browserOptions
+	outputOptions
+	Scope           string
+	WriteAWSProfile bool
+	AWSProfile      string
+	// Write a credential_process entry instead of the keys
+	AWSCredentialProcess bool
+	WriteAzureCLIConfig  bool
+	AzureConfigDir       string
 }
 
 func getCredentialCmd() *cobra.Command {
@@ -84,13 +226,45 @@ func getCredentialCmd() *cobra.Command {
 		Use:   string(models.PsGetCredential),
 		Short: models.CommandDescriptions[models.PsGetCredential],
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cred, error := ps.LoadSandboxCredential()
+			if err := opts.parse(); err != nil {
+				return err
+			}
+			scope, err := models.ParseCredentialScope(opts.Scope)
+			if err != nil {
+				return err
+			}
+
+			profileName, err := ps.ResolveProfile(profile)
+			if err != nil {
+				return err
+			}
+			browserOpts, err := opts.options(profileName)
+			if err != nil {
+				return err
+			}
+
+			cred, error := ps.LoadSandboxCredential(profileName)
 			if error != nil {
 				return error
 			}
-			prompt.PrintStdOut(cred.ToEnvMap(), models.LINUX_ENV_VAR)
-			if opts.Login {
-				ps.LoginAzurePortalFromSandbox()
+			if opts.PrintURL && cred.Provider() != models.ProviderAWS {
+				return errPrintURLNotAWS
+			}
+			if opts.WriteAWSProfile {
+				if err := ps.WriteAWSSharedProfile(profileName, cred, opts.AWSProfile, opts.AWSCredentialProcess); err != nil {
+					return err
+				}
+			}
+			if opts.WriteAzureCLIConfig {
+				if err := writeAzureCLIConfig(profileName, cred, opts.AzureConfigDir); err != nil {
+					return err
+				}
+			}
+			if err := opts.print(cred, cred.ToScopedEnvMap(scope)); err != nil {
+				return err
+			}
+			if opts.Login || opts.PrintURL {
+				return login(cred, browserOpts, opts.PrintURL)
 			}
 
 			return nil
@@ -100,6 +274,11 @@ func getCredentialCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&opts.HTMLPath, "html-path", "", "", "Path of HTML file")
 	cmd.Flags().BoolVarP(&opts.UseClipboard, "clipboard", "", true, "Read HTML from clipboard")
 	cmd.Flags().BoolVarP(&opts.Login, "login", "", false, "Will login or not")
+	addBrowserFlags(cmd, &opts.browserOptions)
+	addOutputFlags(cmd, &opts.outputOptions, "env")
+	addScopeFlag(cmd, &opts.Scope)
+	addAWSProfileFlags(cmd, &opts.WriteAWSProfile, &opts.AWSProfile, &opts.AWSCredentialProcess)
+	addAzureCLIFlags(cmd, &opts.WriteAzureCLIConfig, &opts.AzureConfigDir)
 
 	return cmd
 }
@@ -116,7 +295,12 @@ func initCredentialCmd() *cobra.Command {
 		Use:   string(models.PsInitCredential),
 		Short: models.CommandDescriptions[models.PsInitCredential],
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ps.ReplaceResourceGroupInPath(opts.Path, opts.SandboxPath)
+			profileName, err := ps.ResolveProfile(profile)
+			if err != nil {
+				return err
+			}
+
+			ps.ReplaceResourceGroupInPath(opts.Path, opts.SandboxPath, profileName)
 			ps.RemoveTerraformStateFiles(opts.Path)
 			return nil
 		},
@@ -129,21 +313,244 @@ func initCredentialCmd() *cobra.Command {
 }
 
 func loginCmd() *cobra.Command {
+	opts := &browserOptions{}
+
 	cmd := &cobra.Command{
 		Use:   string(models.PsLoginByCredential),
 		Short: models.CommandDescriptions[models.PsLoginByCredential],
 		RunE: func(cmd *cobra.Command, args []string) error {
-			sandboxUrl := os.Getenv("ARM_SANDBOX_URL")
-			username := os.Getenv("ARM_USERNAME")
-			password := os.Getenv("ARM_PASSWORD")
-			if username != "" && password != "" {
-				browser.LoginInBrowser(username, password, browser.AzurePortal, sandboxUrl)
+			profileName, err := ps.ResolveProfile(profile)
+			if err != nil {
+				return err
+			}
+			browserOpts, err := opts.options(profileName)
+			if err != nil {
+				return err
+			}
+
+			// Portal logins exported by get-cred stand in for the current profile, but never for one named with --profile
+			var cred models.SandboxCredential
+			if !cmd.Flags().Changed("profile") {
+				if cred, err = ps.SandboxCredentialFromEnv(); err != nil {
+					return err
+				}
+			}
+			if cred == nil {
+				if cred, err = ps.LoadSandboxCredential(profileName); err != nil {
+					return err
+				}
+			}
+
+			// No credential is printed here, so the URL goes to stdout where scripts can capture it
+			if opts.PrintURL {
+				return printSigninURL(os.Stdout, cred)
+			}
+			return login(cred, browserOpts, false)
+		},
+	}
+
+	addBrowserFlags(cmd, opts)
+
+	return cmd
+}
+
+type psExecOptions struct {
+	Scope string
+}
+
+func execCmd() *cobra.Command {
+	opts := &psExecOptions{}
+
+	cmd := &cobra.Command{
+		Use:   string(models.PsExec) + " -- <command> [args...]",
+		Short: models.CommandDescriptions[models.PsExec],
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scope, err := models.ParseCredentialScope(opts.Scope)
+			if err != nil {
+				return err
+			}
+
+			profileName, err := ps.ResolveProfile(profile)
+			if err != nil {
+				return err
+			}
+
+			cred, err := ps.LoadSandboxCredential(profileName)
+			if err != nil {
+				return err
+			}
+
+			code, err := ps.ExecWithCredential(cred, scope, args)
+			if err != nil {
+				return err
+			}
+			if code != 0 {
+				os.Exit(code)
+			}
+			return nil
+		},
+	}
+
+	// Stop parsing flags at the first positional argument so the child's flags pass through untouched
+	cmd.Flags().SetInterspersed(false)
+	addScopeFlag(cmd, &opts.Scope)
+
+	return cmd
+}
+
+func statusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   string(models.PsStatus),
+		Short: models.CommandDescriptions[models.PsStatus],
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profileName, err := ps.ResolveProfile(profile)
+			if err != nil {
+				return err
+			}
+
+			cred, err := ps.LoadSandboxCredential(profileName)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Profile:   %s\n", profileName)
+			fmt.Printf("Provider:  %s\n", cred.Provider())
+
+			expiresAt := cred.ExpiresAt()
+			switch {
+			case expiresAt.IsZero():
+				fmt.Println("Expires:   unknown")
+			case cred.IsExpired():
+				fmt.Printf("Expires:   %s\n", expiresAt.Local().Format(time.RFC3339))
+				fmt.Println("Time left: expired")
+				// An expired sandbox is a result, not a misuse of the command
+				cmd.SilenceUsage = true
+				return &ps.SandboxExpiredError{Profile: profileName, ExpiredAt: expiresAt}
+			default:
+				fmt.Printf("Expires:   %s\n", expiresAt.Local().Format(time.RFC3339))
+				fmt.Printf("Time left: %s\n", time.Until(expiresAt).Truncate(time.Second))
+			}
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+type purgeCredentialOptions struct {
+	All bool
+}
+
+func purgeCredentialCmd() *cobra.Command {
+	opts := &purgeCredentialOptions{}
+
+	cmd := &cobra.Command{
+		Use:   string(models.PsPurgeCredential),
+		Short: models.CommandDescriptions[models.PsPurgeCredential],
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var profiles []string
+			if opts.All {
+				all, err := ps.ListProfiles()
+				if err != nil {
+					return err
+				}
+				profiles = all
 			} else {
-				ps.LoginAzurePortalFromSandbox()
+				profileName, err := ps.ResolveProfile(profile)
+				if err != nil {
+					return err
+				}
+				exists, err := ps.ProfileExists(profileName)
+				if err != nil {
+					return err
+				}
+				if !exists {
+					return fmt.Errorf("profile %q does not exist", profileName)
+				}
+				profiles = []string{profileName}
+			}
+
+			for _, p := range profiles {
+				if err := ps.PurgeSandboxCredential(p); err != nil {
+					return err
+				}
+				fmt.Printf("Purged credential for profile %s\n", p)
 			}
 			return nil
 		},
 	}
 
+	cmd.Flags().BoolVarP(&opts.All, "all", "", false, "Purge the credentials of every profile")
+
 	return cmd
 }
+
+func rulesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   string(models.PsRules),
+		Short: models.CommandDescriptions[models.PsRules],
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := os.Stdout.Write(ps.DefaultExtractionRules())
+			return err
+		},
+	}
+}
+
+func awsCredentialProcessCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   string(models.PsAWSCredentialProcess),
+		Short: models.CommandDescriptions[models.PsAWSCredentialProcess],
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			profileName, err := ps.ResolveProfile(profile)
+			if err != nil {
+				return err
+			}
+
+			output, err := ps.AWSCredentialProcess(profileName)
+			if err != nil {
+				return err
+			}
+
+			return json.NewEncoder(os.Stdout).Encode(output)
+		},
+	}
+}
+
+// Writes the Azure CLI config and tells the user, on stderr so it stays out of the exported variables, how to use it.
+func writeAzureCLIConfig(profileName string, cred models.SandboxCredential, dir string) error {
+	dir, err := ps.WriteAzureCLIConfig(profileName, cred, dir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Azure CLI logged in to the sandbox, run: export AZURE_CONFIG_DIR=%s\n", dir)
+	return nil
+}
+
+// Logs in to the credential's console, or with printURL prints the AWS console sign-in URL to stderr so it never
+// mixes with the credential output.
+func login(cred models.SandboxCredential, opts browser.Options, printURL bool) error {
+	if !printURL {
+		return ps.LoginWithCredential(cred, opts)
+	}
+	return printSigninURL(os.Stderr, cred)
+}
+
+func printSigninURL(w io.Writer, cred models.SandboxCredential) error {
+	awsCred, ok := cred.(*models.PsAwsCredential)
+	if !ok {
+		return errPrintURLNotAWS
+	}
+	url, err := ps.AWSConsoleSigninURL(awsCred)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, url)
+	return nil
+}
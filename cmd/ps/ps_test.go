@@ -0,0 +1,38 @@
+package ps
+
+import (
+	"bear_cli/internal/ps"
+	"bear_cli/internal/secretstore"
+	"bear_cli/models"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetCredentialPrintURLNotAWS(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, err := secretstore.New(secretstore.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred := &models.PsAzureCredential{User: "cloud_user", Password: "s3cr3t"}
+	if err := ps.SaveSandboxCredential(cred, "azure-lab", store); err != nil {
+		t.Fatal(err)
+	}
+
+	outFile := filepath.Join(t.TempDir(), "cred.env")
+	cmd := getCredentialCmd()
+	cmd.SetArgs([]string{"--print-url", "--out-file", outFile})
+	cmd.SetErr(io.Discard)
+	profile = "azure-lab"
+	t.Cleanup(func() { profile = "" })
+
+	if err := cmd.Execute(); !errors.Is(err, errPrintURLNotAWS) {
+		t.Fatalf("err = %v, want %v", err, errPrintURLNotAWS)
+	}
+	if _, err := os.Stat(outFile); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("credential written before --print-url was refused: %v", err)
+	}
+}
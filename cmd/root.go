@@ -4,6 +4,7 @@ import (
 	"bear_cli/cmd/ado"
 	"bear_cli/cmd/ps"
 	"log"
+	"os"
 
 	"github.com/spf13/cobra"
 )
@@ -22,6 +23,7 @@ func init() {
 
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal(err)
+		log.Print(err)
+		os.Exit(ps.ExitCode(err))
 	}
 }
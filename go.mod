@@ -2,9 +2,15 @@ module bear_cli
 
 go 1.26.0
 
-require github.com/PuerkitoBio/goquery v1.11.0
+require (
+	filippo.io/age v1.3.1
+	github.com/PuerkitoBio/goquery v1.11.0
+	github.com/godbus/dbus/v5 v5.2.2
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
+	filippo.io/hpke v0.4.0 // indirect
 	github.com/chromedp/cdproto v0.0.0-20250724212937-08a3db8b4327 // indirect
 	github.com/chromedp/sysutil v1.1.0 // indirect
 	github.com/go-json-experiment/json v0.0.0-20250725192818-e39067aee2d2 // indirect
@@ -13,11 +19,12 @@ require (
 	github.com/gobwas/ws v1.4.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
+	golang.org/x/crypto v0.45.0 // indirect
 	golang.org/x/sys v0.39.0 // indirect
 )
 
 require (
-	github.com/andybalholm/cascadia v1.3.3 // indirect
+	github.com/andybalholm/cascadia v1.3.3
 	github.com/atotto/clipboard v0.1.4
 	github.com/chromedp/chromedp v0.14.2
 	github.com/spf13/cobra v1.10.1
@@ -9,7 +9,26 @@ import (
 	"net/url"
 )
 
-func GetARMToken(clientID, clientSecret, tenant string) string {
+// Raised when Entra ID does not hand out an access token for the service principal.
+type TokenExchangeError struct {
+	Tenant     string
+	StatusCode int
+	Message    string
+	Err        error
+}
+
+func (e *TokenExchangeError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("token exchange for tenant %s failed: %v", e.Tenant, e.Err)
+	}
+	return fmt.Sprintf("token exchange for tenant %s failed (HTTP %d): %s", e.Tenant, e.StatusCode, e.Message)
+}
+
+func (e *TokenExchangeError) Unwrap() error {
+	return e.Err
+}
+
+func GetARMToken(clientID, clientSecret, tenant string) (string, error) {
 	tokenURL := fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token", tenant)
 
 	form := url.Values{}
@@ -24,14 +43,28 @@ func GetARMToken(clientID, clientSecret, tenant string) string {
 		bytes.NewBufferString(form.Encode()),
 	)
 	if err != nil {
-		panic(err)
+		return "", &TokenExchangeError{Tenant: tenant, Err: err}
 	}
 	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", &TokenExchangeError{Tenant: tenant, StatusCode: resp.StatusCode, Err: err}
+	}
+
 	var tokenResp map[string]any
-	json.Unmarshal(body, &tokenResp)
-	token := tokenResp["access_token"].(string)
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", &TokenExchangeError{Tenant: tenant, StatusCode: resp.StatusCode, Message: string(body)}
+	}
+
+	token, ok := tokenResp["access_token"].(string)
+	if resp.StatusCode != http.StatusOK || !ok || token == "" {
+		message, _ := tokenResp["error_description"].(string)
+		if message == "" {
+			message = "response has no access_token"
+		}
+		return "", &TokenExchangeError{Tenant: tenant, StatusCode: resp.StatusCode, Message: message}
+	}
 
-	return token
+	return token, nil
 }
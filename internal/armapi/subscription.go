@@ -2,40 +2,52 @@ package armapi
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 )
 
 // Retrieves the tenant ID associated with the Azure subscription using the provided ARM token.
-func GetTenantId(token string) string {
-	req, _ := http.NewRequest(
+func GetTenantId(token string) (string, error) {
+	req, err := http.NewRequest(
 		"GET",
 		"https://management.azure.com/subscriptions?api-version=2025-04-01",
 		nil,
 	)
+	if err != nil {
+		return "", err
+	}
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 
 	httpClient := &http.Client{}
 	subResp, err := httpClient.Do(req)
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("failed to list subscriptions: %w", err)
 	}
 	defer subResp.Body.Close()
 
-	subBody, _ := io.ReadAll(subResp.Body)
+	subBody, err := io.ReadAll(subResp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	if subResp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to list subscriptions (HTTP %d): %s", subResp.StatusCode, subBody)
+	}
 
 	var data map[string]any
 	if err := json.Unmarshal(subBody, &data); err != nil {
-		panic(err)
+		return "", fmt.Errorf("failed to decode subscriptions: %w", err)
 	}
 
 	if valueArr, ok := data["value"].([]any); ok && len(valueArr) > 0 {
 		if firstSub, ok := valueArr[0].(map[string]any); ok {
 			if tenantID, ok := firstSub["tenantId"].(string); ok {
-				return tenantID
+				return tenantID, nil
 			}
 		}
 	}
-	return ""
+	return "", errors.New("no subscription with a tenant ID is visible to the service principal")
 }
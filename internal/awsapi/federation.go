@@ -0,0 +1,225 @@
+package awsapi
+
+import (
+	"bear_cli/models"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultSTSEndpoint        = "https://sts.amazonaws.com"
+	DefaultFederationEndpoint = "https://signin.aws.amazon.com/federation"
+)
+
+// Lets the sandbox user into the console without its password.
+const federatedPolicy = `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`
+
+const issuer = "bear"
+
+// Raised when STS or the federation endpoint refuses to sign the user in.
+type FederationError struct {
+	Step       string
+	StatusCode int
+	Message    string
+	Err        error
+}
+
+func (e *FederationError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("AWS console federation failed at %s: %v", e.Step, e.Err)
+	}
+	return fmt.Sprintf("AWS console federation failed at %s (HTTP %d): %s", e.Step, e.StatusCode, e.Message)
+}
+
+func (e *FederationError) Unwrap() error {
+	return e.Err
+}
+
+// Builds console sign-in URLs from access keys. The endpoints can point at a stub.
+type FederationClient struct {
+	STSEndpoint        string
+	FederationEndpoint string
+	HTTPClient         *http.Client
+}
+
+// Uses the public AWS endpoints unless AWS_ENDPOINT_URL_STS or BEAR_AWS_FEDERATION_ENDPOINT replace them.
+func NewFederationClient() *FederationClient {
+	client := &FederationClient{
+		STSEndpoint:        DefaultSTSEndpoint,
+		FederationEndpoint: DefaultFederationEndpoint,
+		HTTPClient:         &http.Client{Timeout: 30 * time.Second},
+	}
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL_STS"); endpoint != "" {
+		client.STSEndpoint = endpoint
+	}
+	if endpoint := os.Getenv("BEAR_AWS_FEDERATION_ENDPOINT"); endpoint != "" {
+		client.FederationEndpoint = endpoint
+	}
+
+	return client
+}
+
+type getFederationTokenResponse struct {
+	Credentials struct {
+		AccessKeyId     string
+		SecretAccessKey string
+		SessionToken    string
+	} `xml:"GetFederationTokenResult>Credentials"`
+}
+
+type stsErrorResponse struct {
+	Message string `xml:"Error>Message"`
+}
+
+var regionalSTSHost = regexp.MustCompile(`(?:^|\.)sts(?:-fips)?\.([a-z]{2}(?:-[a-z]+)+-\d+)\.(?:vpce\.)?amazonaws\.com(?:\.cn)?(?::\d+)?$`)
+
+// Regional STS endpoints only accept requests signed for their own region and the global one signs in us-east-1.
+// Any other endpoint, such as a local stub, is signed for the sandbox region.
+func signingRegion(host, fallback string) string {
+	if m := regionalSTSHost.FindStringSubmatch(host); m != nil {
+		return m[1]
+	}
+	if fallback == "" || strings.HasSuffix(strings.Split(host, ":")[0], ".amazonaws.com") {
+		return "us-east-1"
+	}
+	return fallback
+}
+
+// Exchanges long-lived IAM user keys for temporary ones, which the federation endpoint requires.
+func (c *FederationClient) getFederationToken(cred models.AWSCredential, name string) (models.AWSCredential, error) {
+	form := url.Values{}
+	form.Set("Action", "GetFederationToken")
+	form.Set("Version", "2011-06-15")
+	form.Set("Name", name)
+	form.Set("Policy", federatedPolicy)
+	form.Set("DurationSeconds", "3600")
+	body := []byte(form.Encode())
+
+	req, err := http.NewRequest(http.MethodPost, c.STSEndpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return cred, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signV4(req, body, cred, signingRegion(req.URL.Host, cred.Region), "sts", time.Now())
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return cred, &FederationError{Step: "GetFederationToken", Err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return cred, &FederationError{Step: "GetFederationToken", StatusCode: resp.StatusCode, Err: err}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var stsErr stsErrorResponse
+		message := string(data)
+		if xml.Unmarshal(data, &stsErr) == nil && stsErr.Message != "" {
+			message = stsErr.Message
+		}
+		return cred, &FederationError{Step: "GetFederationToken", StatusCode: resp.StatusCode, Message: message}
+	}
+
+	var tokenResp getFederationTokenResponse
+	if err := xml.Unmarshal(data, &tokenResp); err != nil {
+		return cred, &FederationError{Step: "GetFederationToken", StatusCode: resp.StatusCode, Err: err}
+	}
+
+	return models.AWSCredential{
+		AccessKeyId:     tokenResp.Credentials.AccessKeyId,
+		SecretAccessKey: tokenResp.Credentials.SecretAccessKey,
+		SessionToken:    tokenResp.Credentials.SessionToken,
+		Region:          cred.Region,
+	}, nil
+}
+
+func (c *FederationClient) getSigninToken(cred models.AWSCredential) (string, error) {
+	session, err := json.Marshal(map[string]string{
+		"sessionId":    cred.AccessKeyId,
+		"sessionKey":   cred.SecretAccessKey,
+		"sessionToken": cred.SessionToken,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("Action", "getSigninToken")
+	query.Set("Session", string(session))
+
+	resp, err := c.HTTPClient.Get(c.FederationEndpoint + "?" + query.Encode())
+	if err != nil {
+		return "", &FederationError{Step: "getSigninToken", Err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", &FederationError{Step: "getSigninToken", StatusCode: resp.StatusCode, Err: err}
+	}
+
+	var tokenResp struct {
+		SigninToken string
+	}
+	if resp.StatusCode != http.StatusOK || json.Unmarshal(data, &tokenResp) != nil || tokenResp.SigninToken == "" {
+		return "", &FederationError{Step: "getSigninToken", StatusCode: resp.StatusCode, Message: string(data)}
+	}
+
+	return tokenResp.SigninToken, nil
+}
+
+// Returns a URL that opens the console signed in as a federated user holding the credential's permissions.
+// Temporary credentials are used as they are; IAM user keys are first exchanged through STS.
+func (c *FederationClient) ConsoleSigninURL(cred models.AWSCredential, user string) (string, error) {
+	if cred.SessionToken == "" {
+		federated, err := c.getFederationToken(cred, federationUserName(user))
+		if err != nil {
+			return "", err
+		}
+		cred = federated
+	}
+
+	token, err := c.getSigninToken(cred)
+	if err != nil {
+		return "", err
+	}
+
+	destination := "https://console.aws.amazon.com/"
+	if cred.Region != "" {
+		destination = fmt.Sprintf("https://%s.console.aws.amazon.com/console/home?region=%s", cred.Region, cred.Region)
+	}
+
+	query := url.Values{}
+	query.Set("Action", "login")
+	query.Set("Issuer", issuer)
+	query.Set("Destination", destination)
+	query.Set("SigninToken", token)
+
+	return c.FederationEndpoint + "?" + query.Encode(), nil
+}
+
+// STS limits federated user names to 2-32 characters of [\w+=,.@-].
+func federationUserName(user string) string {
+	name := []rune{}
+	for _, r := range "bear-" + user {
+		if r < 128 && (r == '_' || r == '+' || r == '=' || r == ',' || r == '.' || r == '@' || r == '-' ||
+			('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')) {
+			name = append(name, r)
+		}
+	}
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return string(name)
+}
@@ -0,0 +1,200 @@
+package awsapi
+
+import (
+	"bear_cli/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const getFederationTokenResult = `<GetFederationTokenResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetFederationTokenResult>
+    <Credentials>
+      <AccessKeyId>ASIAFEDERATED</AccessKeyId>
+      <SecretAccessKey>federated-secret</SecretAccessKey>
+      <SessionToken>federated-token</SessionToken>
+    </Credentials>
+  </GetFederationTokenResult>
+</GetFederationTokenResponse>`
+
+const stsAccessDenied = `<ErrorResponse><Error><Code>AccessDenied</Code><Message>not authorized to perform sts:GetFederationToken</Message></Error></ErrorResponse>`
+
+// Stands in for STS and the federation endpoint, recording what bear sent them.
+type federationStub struct {
+	stsStatus        int
+	stsBody          string
+	federationStatus int
+	federationBody   string
+
+	stsForm       url.Values
+	stsAuth       string
+	session       map[string]string
+	stsCalls      int
+	federationURL string
+}
+
+func (s *federationStub) client(t *testing.T) *FederationClient {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /sts/", func(w http.ResponseWriter, r *http.Request) {
+		s.stsCalls++
+		s.stsAuth = r.Header.Get("Authorization")
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		s.stsForm = r.PostForm
+		w.WriteHeader(s.stsStatus)
+		w.Write([]byte(s.stsBody))
+	})
+	mux.HandleFunc("GET /federation", func(w http.ResponseWriter, r *http.Request) {
+		if action := r.URL.Query().Get("Action"); action != "getSigninToken" {
+			t.Errorf("federation Action = %s", action)
+		}
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("Session")), &s.session); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(s.federationStatus)
+		w.Write([]byte(s.federationBody))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	s.federationURL = server.URL + "/federation"
+	return &FederationClient{
+		STSEndpoint:        server.URL + "/sts",
+		FederationEndpoint: s.federationURL,
+		HTTPClient:         server.Client(),
+	}
+}
+
+func TestConsoleSigninURL(t *testing.T) {
+	iamUser := models.AWSCredential{AccessKeyId: "AKIAUSER", SecretAccessKey: "user-secret", Region: "eu-west-1"}
+	session := models.AWSCredential{AccessKeyId: "ASIASESSION", SecretAccessKey: "session-secret", SessionToken: "session-token"}
+
+	tests := []struct {
+		name            string
+		cred            models.AWSCredential
+		stub            federationStub
+		wantSTSCalls    int
+		wantSession     map[string]string
+		wantDestination string
+		wantStep        string
+	}{
+		{
+			name:         "session credential",
+			cred:         session,
+			stub:         federationStub{federationStatus: http.StatusOK, federationBody: `{"SigninToken":"token-123"}`},
+			wantSTSCalls: 0,
+			wantSession: map[string]string{
+				"sessionId": "ASIASESSION", "sessionKey": "session-secret", "sessionToken": "session-token",
+			},
+			wantDestination: "https://console.aws.amazon.com/",
+		},
+		{
+			name: "IAM user keys",
+			cred: iamUser,
+			stub: federationStub{
+				stsStatus: http.StatusOK, stsBody: getFederationTokenResult,
+				federationStatus: http.StatusOK, federationBody: `{"SigninToken":"token-123"}`,
+			},
+			wantSTSCalls: 1,
+			wantSession: map[string]string{
+				"sessionId": "ASIAFEDERATED", "sessionKey": "federated-secret", "sessionToken": "federated-token",
+			},
+			wantDestination: "https://eu-west-1.console.aws.amazon.com/console/home?region=eu-west-1",
+		},
+		{
+			name:         "user may not federate",
+			cred:         iamUser,
+			stub:         federationStub{stsStatus: http.StatusForbidden, stsBody: stsAccessDenied},
+			wantSTSCalls: 1,
+			wantStep:     "GetFederationToken",
+		},
+		{
+			name:         "federation endpoint refuses the session",
+			cred:         session,
+			stub:         federationStub{federationStatus: http.StatusBadRequest, federationBody: "invalid session"},
+			wantSTSCalls: 0,
+			wantStep:     "getSigninToken",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := tt.stub
+			client := stub.client(t)
+
+			signinURL, err := client.ConsoleSigninURL(tt.cred, "cloud_user")
+
+			if stub.stsCalls != tt.wantSTSCalls {
+				t.Errorf("STS called %d times, want %d", stub.stsCalls, tt.wantSTSCalls)
+			}
+			if stub.stsCalls > 0 {
+				if got := stub.stsForm.Get("Action"); got != "GetFederationToken" {
+					t.Errorf("STS Action = %s", got)
+				}
+				if got := stub.stsForm.Get("Name"); got != "bear-cloud_user" {
+					t.Errorf("federated user name = %s", got)
+				}
+				// A stub endpoint is signed for the sandbox region
+				if !strings.HasPrefix(stub.stsAuth, "AWS4-HMAC-SHA256 Credential=AKIAUSER/") || !strings.Contains(stub.stsAuth, "/eu-west-1/sts/aws4_request") {
+					t.Errorf("STS request signed as %s", stub.stsAuth)
+				}
+			}
+
+			if tt.wantStep != "" {
+				var fedErr *FederationError
+				if !errors.As(err, &fedErr) || fedErr.Step != tt.wantStep {
+					t.Fatalf("err = %v, want a FederationError at %s", err, tt.wantStep)
+				}
+				if tt.wantStep == "GetFederationToken" && fedErr.Message != "not authorized to perform sts:GetFederationToken" {
+					t.Errorf("message = %q, want the STS error message", fedErr.Message)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for k, v := range tt.wantSession {
+				if stub.session[k] != v {
+					t.Errorf("session %s = %q, want %q", k, stub.session[k], v)
+				}
+			}
+
+			parsed, err := url.Parse(signinURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if base := strings.TrimSuffix(signinURL, "?"+parsed.RawQuery); base != stub.federationURL {
+				t.Errorf("sign-in URL points at %s, want %s", base, stub.federationURL)
+			}
+			query := parsed.Query()
+			for k, want := range map[string]string{
+				"Action": "login", "Issuer": "bear", "SigninToken": "token-123", "Destination": tt.wantDestination,
+			} {
+				if got := query.Get(k); got != want {
+					t.Errorf("%s = %q, want %q", k, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFederationUserName(t *testing.T) {
+	tests := map[string]string{
+		"cloud_user":                            "bear-cloud_user",
+		"cloud user/with#odd*chars":             "bear-clouduserwithoddchars",
+		"a-very-long-sandbox-user-name-indeed!": "bear-a-very-long-sandbox-user-na",
+	}
+	for in, want := range tests {
+		if got := federationUserName(in); got != want {
+			t.Errorf("federationUserName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
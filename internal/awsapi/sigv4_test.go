@@ -0,0 +1,108 @@
+package awsapi
+
+import (
+	"bear_cli/models"
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Known answers from the AWS Signature Version 4 test suite, which signs for service "service" in us-east-1.
+func TestSignV4(t *testing.T) {
+	cred := models.AWSCredential{
+		AccessKeyId:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			name:   "get-vanilla",
+			method: http.MethodGet,
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:   "post-vanilla",
+			method: http.MethodPost,
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name:        "post-x-www-form-urlencoded",
+			method:      http.MethodPost,
+			contentType: "application/x-www-form-urlencoded",
+			body:        "Param1=value1",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date, Signature=ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := []byte(tt.body)
+			req, err := http.NewRequest(tt.method, "https://example.amazonaws.com/", bytes.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			signV4(req, body, cred, "us-east-1", "service", now)
+
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("X-Amz-Date = %s", got)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.want {
+				t.Errorf("Authorization =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignV4SessionToken(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://sts.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signV4(req, nil, models.AWSCredential{AccessKeyId: "ASIA", SecretAccessKey: "secret", SessionToken: "token"}, "us-east-1", "sts", time.Now())
+
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "token" {
+		t.Errorf("X-Amz-Security-Token = %q, want token", got)
+	}
+	if auth := req.Header.Get("Authorization"); !strings.Contains(auth, "SignedHeaders=host;x-amz-date;x-amz-security-token,") {
+		t.Errorf("session token is not signed: %s", auth)
+	}
+}
+
+func TestSigningRegion(t *testing.T) {
+	tests := []struct {
+		host     string
+		fallback string
+		want     string
+	}{
+		{host: "sts.amazonaws.com", fallback: "eu-west-1", want: "us-east-1"},
+		{host: "sts.eu-west-1.amazonaws.com", fallback: "us-east-1", want: "eu-west-1"},
+		{host: "sts-fips.us-gov-west-1.amazonaws.com", want: "us-gov-west-1"},
+		{host: "sts.cn-north-1.amazonaws.com.cn", want: "cn-north-1"},
+		{host: "vpce-0abc.sts.ap-southeast-2.vpce.amazonaws.com:443", want: "ap-southeast-2"},
+		{host: "127.0.0.1:4566", fallback: "eu-central-1", want: "eu-central-1"},
+		{host: "127.0.0.1:4566", want: "us-east-1"},
+	}
+
+	for _, tt := range tests {
+		if got := signingRegion(tt.host, tt.fallback); got != tt.want {
+			t.Errorf("signingRegion(%q, %q) = %s, want %s", tt.host, tt.fallback, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package awscli
+
+import (
+	"bear_cli/models"
+	"time"
+)
+
+// The JSON document the AWS CLI and SDKs expect on stdout from a credential_process command.
+type CredentialProcessOutput struct {
+	Version         int
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string `json:",omitempty"`
+	Expiration      string `json:",omitempty"`
+}
+
+func NewCredentialProcessOutput(cred models.AWSCredential, expiresAt time.Time) CredentialProcessOutput {
+	output := CredentialProcessOutput{
+		Version:         1,
+		AccessKeyId:     cred.AccessKeyId,
+		SecretAccessKey: cred.SecretAccessKey,
+		SessionToken:    cred.SessionToken,
+	}
+
+	// Without an expiration the SDKs treat the keys as long-lived and never call us again
+	if !expiresAt.IsZero() {
+		output.Expiration = expiresAt.UTC().Format(time.RFC3339)
+	}
+
+	return output
+}
@@ -0,0 +1,66 @@
+package awscli
+
+import (
+	"bear_cli/models"
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNewCredentialProcessOutput(t *testing.T) {
+	session := models.AWSCredential{AccessKeyId: "ASIAEXAMPLE", SecretAccessKey: "secret", SessionToken: "token"}
+	keys := models.AWSCredential{AccessKeyId: "AKIAEXAMPLE", SecretAccessKey: "secret"}
+	expiresAt := time.Date(2026, 10, 18, 16, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	tests := []struct {
+		name      string
+		cred      models.AWSCredential
+		expiresAt time.Time
+		want      map[string]any
+	}{
+		{
+			name:      "session with a known expiry",
+			cred:      session,
+			expiresAt: expiresAt,
+			want: map[string]any{
+				"Version": 1.0, "AccessKeyId": "ASIAEXAMPLE", "SecretAccessKey": "secret", "SessionToken": "token",
+				"Expiration": "2026-10-18T14:30:00Z",
+			},
+		},
+		{
+			name: "unknown expiry",
+			cred: session,
+			want: map[string]any{"Version": 1.0, "AccessKeyId": "ASIAEXAMPLE", "SecretAccessKey": "secret", "SessionToken": "token"},
+		},
+		{
+			name:      "keys without a session token",
+			cred:      keys,
+			expiresAt: expiresAt,
+			want:      map[string]any{"Version": 1.0, "AccessKeyId": "AKIAEXAMPLE", "SecretAccessKey": "secret", "Expiration": "2026-10-18T14:30:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(NewCredentialProcessOutput(tt.cred, tt.expiresAt))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got map[string]any
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("output = %s, want the fields %v", data, slices.Sorted(maps.Keys(tt.want)))
+			}
+			if expiration, ok := got["Expiration"].(string); ok {
+				if _, err := time.Parse(time.RFC3339, expiration); err != nil {
+					t.Errorf("Expiration is not RFC 3339: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package awscli
+
+import (
+	"bear_cli/internal/outfile"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	sectionHeader = regexp.MustCompile(`^\s*\[\s*([^\]]+?)\s*\]\s*(?:[#;].*)?$`)
+	keyLine       = regexp.MustCompile(`^\s*([A-Za-z0-9_.-]+)\s*=`)
+)
+
+// Marks the sections bear wrote, so it never overwrites or removes a profile of the user's own.
+const managedMarker = "# managed by bear"
+
+// A line-preserving view of an INI file, so comments and unrelated sections survive edits.
+type iniFile struct {
+	lines []string
+}
+
+type iniValue struct {
+	key   string
+	value string
+}
+
+func readINI(path string) (*iniFile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &iniFile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	content := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if content == "" {
+		return &iniFile{}, nil
+	}
+
+	return &iniFile{lines: strings.Split(content, "\n")}, nil
+}
+
+func (f *iniFile) write(path string) error {
+	content := ""
+	if len(f.lines) > 0 {
+		content = strings.Join(f.lines, "\n") + "\n"
+	}
+
+	return outfile.WriteAtomic(path, []byte(content))
+}
+
+// Returns the line range [start, end) of the section, where start is its header.
+func (f *iniFile) section(name string) (int, int, bool) {
+	start := -1
+	for i, line := range f.lines {
+		m := sectionHeader.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if start >= 0 {
+			return start, i, true
+		}
+		if m[1] == name {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		return start, len(f.lines), true
+	}
+	return 0, 0, false
+}
+
+// Reports whether the section exists and, if so, whether bear wrote it.
+func (f *iniFile) sectionOwner(name string) (exists bool, managed bool) {
+	start, end, ok := f.section(name)
+	if !ok {
+		return false, false
+	}
+
+	for i := start + 1; i < end; i++ {
+		if strings.TrimSpace(f.lines[i]) == managedMarker {
+			return true, true
+		}
+	}
+	return true, false
+}
+
+// Sets the values in the section, replacing existing keys in place and creating the section if needed.
+// New sections are marked as managed by bear.
+func (f *iniFile) upsertSection(name string, values []iniValue) {
+	start, end, ok := f.section(name)
+	if !ok {
+		if len(f.lines) > 0 && strings.TrimSpace(f.lines[len(f.lines)-1]) != "" {
+			f.lines = append(f.lines, "")
+		}
+		f.lines = append(f.lines, fmt.Sprintf("[%s]", name), managedMarker)
+		for _, v := range values {
+			f.lines = append(f.lines, fmt.Sprintf("%s = %s", v.key, v.value))
+		}
+		return
+	}
+
+	for _, v := range values {
+		replaced := false
+		for i := start + 1; i < end; i++ {
+			if m := keyLine.FindStringSubmatch(f.lines[i]); m != nil && m[1] == v.key {
+				f.lines[i] = fmt.Sprintf("%s = %s", v.key, v.value)
+				replaced = true
+				break
+			}
+		}
+		if replaced {
+			continue
+		}
+
+		// Append after the last key so trailing comments stay attached to the next section
+		insertAt := start + 1
+		for i := start + 1; i < end; i++ {
+			if keyLine.MatchString(f.lines[i]) {
+				insertAt = i + 1
+			}
+		}
+		f.lines = append(f.lines[:insertAt], append([]string{fmt.Sprintf("%s = %s", v.key, v.value)}, f.lines[insertAt:]...)...)
+		end++
+	}
+}
+
+func (f *iniFile) removeSection(name string) bool {
+	start, end, ok := f.section(name)
+	if !ok {
+		return false
+	}
+
+	// Comments right before the next header describe that section, so keep them
+	for end > start+1 && end < len(f.lines) && isCommentOrBlank(f.lines[end-1]) {
+		end--
+	}
+	for end < len(f.lines) && end > start && strings.TrimSpace(f.lines[end-1]) == "" {
+		end--
+	}
+
+	f.lines = append(f.lines[:start], f.lines[end:]...)
+
+	// Drop the blank line that separated the removed section from the previous one
+	for start > 0 && strings.TrimSpace(f.lines[start-1]) == "" && (start == len(f.lines) || strings.TrimSpace(f.lines[start]) == "") {
+		f.lines = append(f.lines[:start-1], f.lines[start:]...)
+		start--
+	}
+
+	return true
+}
+
+func isCommentOrBlank(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";")
+}
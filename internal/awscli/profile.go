@@ -0,0 +1,195 @@
+package awscli
+
+import (
+	"bear_cli/models"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+const DefaultProfile = "bear-sandbox"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Rejects names that would break the section headers of the shared files.
+func ValidateProfileName(profile string) error {
+	if !profileNamePattern.MatchString(profile) {
+		return fmt.Errorf("invalid AWS profile name %q: use letters, digits, '.', '_' and '-'", profile)
+	}
+	return nil
+}
+
+// Raised when the profile to write already exists in a shared file and was not written by bear.
+type UnmanagedProfileError struct {
+	Profile string
+	Path    string
+}
+
+func (e *UnmanagedProfileError) Error() string {
+	return fmt.Sprintf("AWS profile %q in %s was not written by bear: choose another --aws-profile", e.Profile, e.Path)
+}
+
+// Reads a shared file for writing the section, refusing to take over a section bear did not write.
+func readForWrite(path, section, profile string) (*iniFile, error) {
+	file, err := readINI(path)
+	if err != nil {
+		return nil, err
+	}
+	if exists, managed := file.sectionOwner(section); exists && !managed {
+		return nil, &UnmanagedProfileError{Profile: profile, Path: path}
+	}
+	return file, nil
+}
+
+func credentialsPath() (string, error) {
+	if path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); path != "" {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".aws", "credentials"), nil
+}
+
+func configPath() (string, error) {
+	if path := os.Getenv("AWS_CONFIG_FILE"); path != "" {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".aws", "config"), nil
+}
+
+// The config file prefixes every profile but the default with "profile ".
+func configSection(profile string) string {
+	if profile == "default" {
+		return profile
+	}
+	return "profile " + profile
+}
+
+// Upserts the named profile in the shared credentials and config files used by the AWS CLI and SDKs.
+func WriteProfile(profile string, cred models.AWSCredential) error {
+	if err := ValidateProfileName(profile); err != nil {
+		return err
+	}
+
+	credentialsFile, err := credentialsPath()
+	if err != nil {
+		return err
+	}
+	configFile, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	// Check both files before writing either, so a refusal leaves them untouched
+	credentials, err := readForWrite(credentialsFile, profile, profile)
+	if err != nil {
+		return err
+	}
+	config, err := readForWrite(configFile, configSection(profile), profile)
+	if err != nil {
+		return err
+	}
+
+	values := []iniValue{
+		{key: "aws_access_key_id", value: cred.AccessKeyId},
+		{key: "aws_secret_access_key", value: cred.SecretAccessKey},
+	}
+	if cred.SessionToken != "" {
+		values = append(values, iniValue{key: "aws_session_token", value: cred.SessionToken})
+	}
+	credentials.upsertSection(profile, values)
+	if err := credentials.write(credentialsFile); err != nil {
+		return err
+	}
+
+	if cred.Region == "" {
+		return nil
+	}
+
+	config.upsertSection(configSection(profile), []iniValue{
+		{key: "region", value: cred.Region},
+	})
+
+	return config.write(configFile)
+}
+
+// Points the named profile at a credential_process command instead of storing keys in the credentials file.
+func WriteCredentialProcessProfile(profile string, command string, region string) error {
+	if err := ValidateProfileName(profile); err != nil {
+		return err
+	}
+
+	credentialsFile, err := credentialsPath()
+	if err != nil {
+		return err
+	}
+	configFile, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	credentials, err := readForWrite(credentialsFile, profile, profile)
+	if err != nil {
+		return err
+	}
+	config, err := readForWrite(configFile, configSection(profile), profile)
+	if err != nil {
+		return err
+	}
+
+	// Stale static keys would take precedence over credential_process
+	if credentials.removeSection(profile) {
+		if err := credentials.write(credentialsFile); err != nil {
+			return err
+		}
+	}
+
+	values := []iniValue{{key: "credential_process", value: command}}
+	if region != "" {
+		values = append(values, iniValue{key: "region", value: region})
+	}
+	config.upsertSection(configSection(profile), values)
+
+	return config.write(configFile)
+}
+
+// Removes the named profile from the shared credentials and config files, leaving everything else intact.
+// Sections bear did not write are kept.
+func RemoveProfile(profile string) error {
+	for _, target := range []struct {
+		path    func() (string, error)
+		section string
+	}{
+		{credentialsPath, profile},
+		{configPath, configSection(profile)},
+	} {
+		path, err := target.path()
+		if err != nil {
+			return err
+		}
+
+		file, err := readINI(path)
+		if err != nil {
+			return err
+		}
+		if _, managed := file.sectionOwner(target.section); !managed || !file.removeSection(target.section) {
+			continue
+		}
+		if err := file.write(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package awscli
+
+import (
+	"bear_cli/models"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Points the shared files at a temporary directory and seeds them.
+func sharedFiles(t *testing.T, credentials, config string) (string, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	credentialsFile := filepath.Join(dir, "credentials")
+	configFile := filepath.Join(dir, "config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+
+	for path, content := range map[string]string{credentialsFile: credentials, configFile: config} {
+		if content == "" {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return credentialsFile, configFile
+}
+
+func assertFile(t *testing.T, path, want string) {
+	t.Helper()
+
+	got, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("%s =\n%s\nwant\n%s", filepath.Base(path), got, want)
+	}
+}
+
+var sandboxCred = models.AWSCredential{AccessKeyId: "AKIAEXAMPLE", SecretAccessKey: "secret", Region: "us-east-1"}
+
+func TestWriteAndRemoveProfile(t *testing.T) {
+	credentialsFile, configFile := sharedFiles(t,
+		"# my keys\n[default]\naws_access_key_id = AKIAMINE\n\n# work account\n[work]\naws_access_key_id = AKIAWORK\n",
+		"[default]\nregion = eu-west-1\n",
+	)
+
+	if err := WriteProfile("bear-sandbox", sandboxCred); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, credentialsFile, "# my keys\n[default]\naws_access_key_id = AKIAMINE\n\n# work account\n[work]\naws_access_key_id = AKIAWORK\n\n"+
+		"[bear-sandbox]\n# managed by bear\naws_access_key_id = AKIAEXAMPLE\naws_secret_access_key = secret\n")
+	assertFile(t, configFile, "[default]\nregion = eu-west-1\n\n[profile bear-sandbox]\n# managed by bear\nregion = us-east-1\n")
+
+	// Rewriting replaces the keys in place and adds the session token
+	rotated := sandboxCred
+	rotated.AccessKeyId = "ASIAROTATED"
+	rotated.SessionToken = "token"
+	if err := WriteProfile("bear-sandbox", rotated); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, credentialsFile, "# my keys\n[default]\naws_access_key_id = AKIAMINE\n\n# work account\n[work]\naws_access_key_id = AKIAWORK\n\n"+
+		"[bear-sandbox]\n# managed by bear\naws_access_key_id = ASIAROTATED\naws_secret_access_key = secret\naws_session_token = token\n")
+
+	if err := RemoveProfile("bear-sandbox"); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, credentialsFile, "# my keys\n[default]\naws_access_key_id = AKIAMINE\n\n# work account\n[work]\naws_access_key_id = AKIAWORK\n")
+	assertFile(t, configFile, "[default]\nregion = eu-west-1\n")
+}
+
+func TestRemoveProfileKeepsUnmanagedSections(t *testing.T) {
+	credentials := "[bear-sandbox]\naws_access_key_id = AKIAMINE\n"
+	credentialsFile, _ := sharedFiles(t, credentials, "")
+
+	if err := RemoveProfile("bear-sandbox"); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, credentialsFile, credentials)
+}
+
+func TestWriteProfileRefusesUnmanagedSections(t *testing.T) {
+	tests := []struct {
+		name        string
+		profile     string
+		credentials string
+		config      string
+	}{
+		{name: "credentials", profile: "default", credentials: "[default]\naws_access_key_id = AKIAMINE\n"},
+		{name: "config", profile: "work", config: "[profile work]\nregion = eu-west-1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credentialsFile, configFile := sharedFiles(t, tt.credentials, tt.config)
+
+			for _, write := range []func() error{
+				func() error { return WriteProfile(tt.profile, sandboxCred) },
+				func() error {
+					return WriteCredentialProcessProfile(tt.profile, "bear ps aws-credential-process", "us-east-1")
+				},
+			} {
+				var unmanaged *UnmanagedProfileError
+				if err := write(); !errors.As(err, &unmanaged) {
+					t.Errorf("error = %v, want UnmanagedProfileError", err)
+				}
+				assertFile(t, credentialsFile, tt.credentials)
+				assertFile(t, configFile, tt.config)
+			}
+		})
+	}
+}
+
+func TestWriteCredentialProcessProfile(t *testing.T) {
+	credentialsFile, configFile := sharedFiles(t, "", "")
+
+	if err := WriteProfile("lab", sandboxCred); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteCredentialProcessProfile("lab", "bear ps aws-credential-process --profile lab", "us-east-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFile(t, credentialsFile, "")
+	assertFile(t, configFile, "[profile lab]\n# managed by bear\nregion = us-east-1\ncredential_process = bear ps aws-credential-process --profile lab\n")
+}
+
+func TestValidateProfileName(t *testing.T) {
+	sharedFiles(t, "", "")
+
+	for _, name := range []string{"bear-sandbox", "lab_1", "team.dev"} {
+		if err := ValidateProfileName(name); err != nil {
+			t.Errorf("ValidateProfileName(%q) error = %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"", "evil]\n[default", "with space", "a[b", "line\nbreak"} {
+		if err := ValidateProfileName(name); err == nil {
+			t.Errorf("ValidateProfileName(%q) succeeded", name)
+		}
+		if err := WriteProfile(name, sandboxCred); err == nil {
+			t.Errorf("WriteProfile(%q) succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package azcli
+
+import (
+	"bear_cli/internal/outfile"
+	"bear_cli/internal/secretstore"
+	"bear_cli/models"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Files the Azure CLI keeps in AZURE_CONFIG_DIR.
+const (
+	ProfileFile                 = "azureProfile.json"
+	ServicePrincipalEntriesFile = "service_principal_entries.json"
+	TokenCacheFile              = "msal_token_cache.json"
+)
+
+// Left in every directory WriteConfigDir writes, so it never takes over an az login bear did not create.
+const MarkerFile = ".bear"
+
+const cloudName = "AzureCloud"
+
+// Raised when the directory to write already holds an Azure CLI config that bear did not write.
+type UnmanagedConfigDirError struct {
+	Dir    string
+	Reason string
+}
+
+func (e *UnmanagedConfigDirError) Error() string {
+	return fmt.Sprintf("refusing to write the Azure CLI config into %s, %s: choose another --az-config-dir", e.Dir, e.Reason)
+}
+
+type subscriptionUser struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type subscription struct {
+	ID               string           `json:"id"`
+	Name             string           `json:"name"`
+	State            string           `json:"state"`
+	User             subscriptionUser `json:"user"`
+	IsDefault        bool             `json:"isDefault"`
+	TenantID         string           `json:"tenantId"`
+	HomeTenantID     string           `json:"homeTenantId"`
+	EnvironmentName  string           `json:"environmentName"`
+	ManagedByTenants []any            `json:"managedByTenants"`
+}
+
+type azureProfile struct {
+	InstallationID string         `json:"installationId"`
+	Subscriptions  []subscription `json:"subscriptions"`
+}
+
+type servicePrincipalEntry struct {
+	ClientID     string `json:"client_id"`
+	Tenant       string `json:"tenant"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// Writes an Azure CLI config directory logged in as the service principal with its subscription selected,
+// as `az login --service-principal` followed by `az account set` would.
+// The MSAL token cache starts empty; az fills it from the service principal secret on first use.
+func WriteConfigDir(dir string, cred models.ARMCredential, tenant string) error {
+	if cred.ClientID == "" || cred.ClientSecret == "" || cred.SubscriptionID == "" || tenant == "" {
+		return errors.New("the service principal needs a client ID, client secret, subscription ID and tenant")
+	}
+
+	if err := checkConfigDir(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, MarkerFile), nil, 0600); err != nil {
+		return err
+	}
+
+	installationID, err := newInstallationID()
+	if err != nil {
+		return err
+	}
+
+	profile := azureProfile{
+		InstallationID: installationID,
+		Subscriptions: []subscription{{
+			ID:               cred.SubscriptionID,
+			Name:             cred.SubscriptionID,
+			State:            "Enabled",
+			User:             subscriptionUser{Name: cred.ClientID, Type: "servicePrincipal"},
+			IsDefault:        true,
+			TenantID:         tenant,
+			HomeTenantID:     tenant,
+			EnvironmentName:  cloudName,
+			ManagedByTenants: []any{},
+		}},
+	}
+	if err := writeJSON(filepath.Join(dir, ProfileFile), profile); err != nil {
+		return err
+	}
+
+	entries := []servicePrincipalEntry{{ClientID: cred.ClientID, Tenant: tenant, ClientSecret: cred.ClientSecret}}
+	if err := writeJSON(filepath.Join(dir, ServicePrincipalEntriesFile), entries); err != nil {
+		return err
+	}
+
+	// Tokens of a previous sandbox would belong to a service principal that no longer exists
+	return writeJSON(filepath.Join(dir, TokenCacheFile), map[string]any{})
+}
+
+// Removes the files WriteConfigDir wrote, shredding the service principal secret, and keeps whatever else az put there.
+// A directory without the marker was not written by bear and is left alone.
+func RemoveConfigDir(dir string) error {
+	if marked, err := isMarked(dir); err != nil || !marked {
+		return err
+	}
+
+	if err := secretstore.Shred(filepath.Join(dir, ServicePrincipalEntriesFile)); err != nil {
+		return err
+	}
+	for _, name := range []string{ProfileFile, TokenCacheFile, MarkerFile} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Refuses the user's own az login: ~/.azure, the AZURE_CONFIG_DIR in use unless bear wrote it,
+// and any other directory that already has files in it but no marker.
+func checkConfigDir(dir string) error {
+	resolved, err := resolvePath(dir)
+	if err != nil {
+		return err
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		personal, err := resolvePath(filepath.Join(home, ".azure"))
+		if err != nil {
+			return err
+		}
+		if resolved == personal {
+			return &UnmanagedConfigDirError{Dir: dir, Reason: "it is the personal Azure CLI login"}
+		}
+	}
+
+	marked, err := isMarked(dir)
+	if err != nil || marked {
+		return err
+	}
+
+	if current := os.Getenv("AZURE_CONFIG_DIR"); current != "" {
+		current, err := resolvePath(current)
+		if err != nil {
+			return err
+		}
+		if resolved == current {
+			return &UnmanagedConfigDirError{Dir: dir, Reason: "it is the AZURE_CONFIG_DIR in use"}
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if len(entries) > 0 {
+		return &UnmanagedConfigDirError{Dir: dir, Reason: "it is not empty and was not created by bear"}
+	}
+	return nil
+}
+
+func isMarked(dir string) (bool, error) {
+	_, err := os.Stat(filepath.Join(dir, MarkerFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Resolves symlinks in the part of path that exists, so a link to ~/.azure compares equal to it.
+func resolvePath(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, nil
+	}
+	resolvedParent, err := resolvePath(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolvedParent, filepath.Base(path)), nil
+}
+
+func newInstallationID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return outfile.WriteAtomic(path, data)
+}
@@ -0,0 +1,144 @@
+package azcli
+
+import (
+	"bear_cli/models"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testCred = models.ARMCredential{
+	ClientID:       "11111111-1111-1111-1111-111111111111",
+	ClientSecret:   "secret",
+	SubscriptionID: "22222222-2222-2222-2222-222222222222",
+}
+
+const testTenant = "33333333-3333-3333-3333-333333333333"
+
+func TestWriteConfigDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("AZURE_CONFIG_DIR", "")
+
+	personal := filepath.Join(home, ".azure")
+	if err := os.MkdirAll(personal, 0700); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(home, "azure-link")
+	if err := os.Symlink(personal, link); err != nil {
+		t.Fatal(err)
+	}
+
+	foreign := filepath.Join(home, "foreign")
+	if err := os.MkdirAll(foreign, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(foreign, ProfileFile), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	empty := filepath.Join(home, "empty")
+	inUse := filepath.Join(home, "in-use")
+	for _, dir := range []string{empty, inUse} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		dir       string
+		configDir string
+		refused   bool
+	}{
+		{name: "new directory", dir: filepath.Join(home, "new", "az")},
+		{name: "empty directory", dir: empty},
+		{name: "personal login", dir: personal, refused: true},
+		{name: "symlink to personal login", dir: link, refused: true},
+		{name: "personal login through dot-dot", dir: filepath.Join(home, "empty", "..", ".azure"), refused: true},
+		{name: "directory with files of its own", dir: foreign, refused: true},
+		{name: "AZURE_CONFIG_DIR in use", dir: inUse, configDir: inUse, refused: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AZURE_CONFIG_DIR", tt.configDir)
+
+			err := WriteConfigDir(tt.dir, testCred, testTenant)
+
+			var unmanaged *UnmanagedConfigDirError
+			if tt.refused {
+				if !errors.As(err, &unmanaged) {
+					t.Fatalf("err = %v, want UnmanagedConfigDirError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{ProfileFile, ServicePrincipalEntriesFile, TokenCacheFile, MarkerFile} {
+				if _, err := os.Stat(filepath.Join(tt.dir, name)); err != nil {
+					t.Errorf("%s not written: %v", name, err)
+				}
+			}
+		})
+	}
+
+	if entries, _ := os.ReadDir(personal); len(entries) != 0 {
+		t.Errorf("personal login was written to: %v", entries)
+	}
+}
+
+func TestWriteConfigDirRewritesOwnDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := filepath.Join(t.TempDir(), "az")
+
+	if err := WriteConfigDir(dir, testCred, testTenant); err != nil {
+		t.Fatal(err)
+	}
+	// az leaves files of its own next to bear's, which must not make the directory look foreign
+	if err := os.WriteFile(filepath.Join(dir, "az.sess"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	// A directory bear wrote is refreshed even while it is the AZURE_CONFIG_DIR in use
+	t.Setenv("AZURE_CONFIG_DIR", dir)
+	if err := WriteConfigDir(dir, testCred, testTenant); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRemoveConfigDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+
+	if err := WriteConfigDir(dir, testCred, testTenant); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "az.sess"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RemoveConfigDir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "az.sess" {
+		t.Errorf("left %v, want only az.sess", entries)
+	}
+
+	// Without the marker the files are somebody else's
+	if err := os.WriteFile(filepath.Join(dir, ProfileFile), []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := RemoveConfigDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ProfileFile)); err != nil {
+		t.Errorf("unmarked %s removed: %v", ProfileFile, err)
+	}
+}
@@ -1,8 +1,13 @@
 package browser
 
 import (
+	"bear_cli/models"
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/chromedp/chromedp"
@@ -10,14 +15,93 @@ import (
 
 type Website string
 
+// How the browser used for logging in is obtained.
+type Mode string
+
+const (
+	ModeLocal    Mode = "local"
+	ModeHeadless Mode = "headless"
+	// Attach to an already running Chrome through its DevTools endpoint
+	ModeRemote Mode = "remote"
+)
+
+func ModeNames() []string {
+	return []string{string(ModeLocal), string(ModeHeadless), string(ModeRemote)}
+}
+
+func ParseMode(s string) (Mode, error) {
+	switch Mode(strings.ToLower(s)) {
+	case ModeLocal:
+		return ModeLocal, nil
+	case ModeHeadless:
+		return ModeHeadless, nil
+	case ModeRemote:
+		return ModeRemote, nil
+	default:
+		return "", fmt.Errorf("invalid browser mode %q (valid: %s)", s, strings.Join(ModeNames(), ", "))
+	}
+}
+
+const DefaultTimeout = 2 * time.Minute
+
+type Options struct {
+	Mode Mode
+	// DevTools endpoint of the browser used in remote mode, e.g. ws://127.0.0.1:9222/devtools/browser/<id>
+	RemoteURL string
+	// Chrome binary used in local and headless mode instead of the one found on the PATH
+	ChromePath string
+	// Bounds the automated login steps, DefaultTimeout when zero
+	Timeout time.Duration
+	// Return once the login steps are done instead of waiting for ENTER
+	NonInteractive bool
+	// Chrome profile kept between logins; empty for a throwaway incognito window
+	UserDataDir string
+}
+
 const (
-	AWSConsole  Website = ""
+	AWSConsole  Website = "https://console.aws.amazon.com"
 	AzurePortal Website = "https://portal.azure.com"
+	GCPConsole  Website = "https://console.cloud.google.com"
 )
 
+// A console bear can log in to, and the steps that fill in its login form.
+type website struct {
+	provider string
+	login    func(url, username, password string) chromedp.Tasks
+}
+
+var websites = map[Website]website{
+	AWSConsole:  {provider: models.ProviderAWS, login: loginAWSConsole},
+	AzurePortal: {provider: models.ProviderAzure, login: loginAzurePortal},
+	GCPConsole:  {provider: models.ProviderGCP, login: loginGCPConsole},
+}
+
+func WebsiteNames() []string {
+	names := make([]string, 0, len(websites))
+	for w := range websites {
+		names = append(names, string(w))
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Returns the console of a sandbox credential's provider.
+func WebsiteForProvider(provider string) (Website, error) {
+	for w, site := range websites {
+		if site.provider == provider {
+			return w, nil
+		}
+	}
+	return "", fmt.Errorf("no console to log in to for provider %q", provider)
+}
+
 // The AWS console will prevent automatically by push a feedback pop up based on their security design
 // So the function only fills username and password, then user can click login button by themselves.
 func loginAWSConsole(url, username, password string) chromedp.Tasks {
+	if url == "" {
+		url = string(AWSConsole)
+	}
+
 	usernameInputSel := `input[name="username"], input[type="username"]`
 	passwordInputSel := `input[name="password"], input[type="password"]`
 
@@ -31,7 +115,8 @@ func loginAWSConsole(url, username, password string) chromedp.Tasks {
 }
 
 // The Azure portal has a more straightforward login flow, so we can automate the entire process.
-func loginAzurePortal(username, password string) chromedp.Tasks {
+// The sandbox URL is not needed: the portal works out the tenant from the username.
+func loginAzurePortal(_, username, password string) chromedp.Tasks {
 	usernameInputSel := `input[name="loginfmt"], input[type="email"]`
 	passwordInputSel := `input[name="accesspass"], #accesspass`
 	loginBtnSel := `document.querySelector('input[type=submit]')`
@@ -48,6 +133,29 @@ func loginAzurePortal(username, password string) chromedp.Tasks {
 	}
 }
 
+// Google may ask for extra verification on unfamiliar browsers, so the user finishes any remaining steps.
+func loginGCPConsole(url, username, password string) chromedp.Tasks {
+	usernameInputSel := `input[type="email"]`
+	passwordInputSel := `input[type="password"][name="Passwd"], input[type="password"]`
+	nextBtnSel := `document.querySelector('#identifierNext button, #identifierNext')`
+	passwordNextBtnSel := `document.querySelector('#passwordNext button, #passwordNext')`
+
+	if url == "" {
+		url = string(GCPConsole)
+	}
+
+	return chromedp.Tasks{
+		chromedp.Navigate(url),
+		chromedp.WaitVisible(usernameInputSel, chromedp.ByQuery),
+		chromedp.SendKeys(usernameInputSel, username, chromedp.ByQuery),
+		clickIfExistsJS(nextBtnSel),
+		chromedp.WaitVisible(passwordInputSel, chromedp.ByQuery),
+		chromedp.SendKeys(passwordInputSel, password, chromedp.ByQuery),
+		clickIfExistsJS(passwordNextBtnSel),
+		chromedp.Sleep(3 * time.Second),
+	}
+}
+
 func clickIfExistsJS(query string) chromedp.ActionFunc {
 	return func(ctx context.Context) error {
 		var exists bool
@@ -69,29 +177,98 @@ func clickIfExistsJS(query string) chromedp.ActionFunc {
 	}
 }
 
-func LoginInBrowser(username, password string, website Website, url string) {
-	allocCtx, _ := chromedp.NewExecAllocator(context.Background(),
-		append(chromedp.DefaultExecAllocatorOptions[:],
-			chromedp.Flag("headless", false),
-			chromedp.Flag("incognito", true),
-		)...)
-
-	ctx, _ := chromedp.NewContext(allocCtx)
+func loginTasks(website Website, url, username, password string) (chromedp.Tasks, error) {
+	site, ok := websites[website]
+	if !ok {
+		return nil, fmt.Errorf("no login flow for %q (valid: %s)", website, strings.Join(WebsiteNames(), ", "))
+	}
+	return site.login(url, username, password), nil
+}
 
-	switch website {
-	case AWSConsole:
-		if err := chromedp.Run(ctx, loginAWSConsole(url, username, password)); err != nil {
-			fmt.Println("Error:", err)
+func newAllocator(opts Options) (context.Context, context.CancelFunc, error) {
+	switch opts.Mode {
+	case ModeRemote:
+		if opts.RemoteURL == "" {
+			return nil, nil, errors.New("remote browser mode needs --remote-debugging-url")
+		}
+		ctx, cancel := chromedp.NewRemoteAllocator(context.Background(), opts.RemoteURL)
+		return ctx, cancel, nil
+	case ModeLocal, ModeHeadless, "":
+		allocOpts := append(chromedp.DefaultExecAllocatorOptions[:],
+			chromedp.Flag("headless", opts.Mode == ModeHeadless),
+			chromedp.Flag("incognito", opts.UserDataDir == ""),
+		)
+		if opts.UserDataDir != "" {
+			if err := os.MkdirAll(opts.UserDataDir, 0700); err != nil {
+				return nil, nil, err
+			}
+			allocOpts = append(allocOpts, chromedp.UserDataDir(opts.UserDataDir))
+		}
+		if opts.ChromePath != "" {
+			allocOpts = append(allocOpts, chromedp.ExecPath(opts.ChromePath))
 		}
-	case AzurePortal:
-		if err := chromedp.Run(ctx, loginAzurePortal(username, password)); err != nil {
-			fmt.Println("Error:", err)
+		ctx, cancel := chromedp.NewExecAllocator(context.Background(), allocOpts...)
+		return ctx, cancel, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown browser mode %q", opts.Mode)
+	}
+}
+
+func LoginInBrowser(username, password string, website Website, url string, opts Options) error {
+	tasks, err := loginTasks(website, url, username, password)
+	if err != nil {
+		return err
+	}
+
+	return run(tasks, opts)
+}
+
+// Opens a page that needs no typing, such as a federation sign-in link.
+func OpenInBrowser(url string, opts Options) error {
+	return run(chromedp.Tasks{chromedp.Navigate(url)}, opts)
+}
+
+func run(tasks chromedp.Tasks, opts Options) error {
+	allocCtx, cancelAlloc, err := newAllocator(opts)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := chromedp.NewContext(allocCtx)
+
+	// A remote browser belongs to someone else: leave it and the tab running when we are done
+	if opts.Mode != ModeRemote {
+		defer cancelAlloc()
+		defer cancel()
+	}
+
+	// Start the browser first so the timeout only bounds the login steps, not the browser's lifetime
+	if err := chromedp.Run(ctx); err != nil {
+		return fmt.Errorf("failed to start the browser: %w", err)
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	loginCtx, cancelLogin := context.WithTimeout(ctx, timeout)
+	defer cancelLogin()
+
+	if err := chromedp.Run(loginCtx, tasks); err != nil {
+		return fmt.Errorf("browser login failed: %w", err)
+	}
+
+	if opts.NonInteractive || opts.Mode == ModeHeadless {
+		// The browser is closed on return, and with it an incognito session
+		if opts.NonInteractive && (opts.Mode == ModeLocal || opts.Mode == "") && opts.UserDataDir == "" {
+			fmt.Fprintln(os.Stderr, "Closing the browser: the session is lost, use --persistent-browser to keep it for the next login.")
 		}
+		return nil
 	}
 
-	fmt.Println("Browser is open. You may continue interacting manually.")
-	fmt.Println("Press ENTER to terminate Go process (browser will close).")
+	fmt.Fprintln(os.Stderr, "Browser is open. You may continue interacting manually.")
+	fmt.Fprintln(os.Stderr, "Press ENTER to terminate Go process (browser will close).")
 
 	// Keep program alive until user decides
 	fmt.Scanln()
+	return nil
 }
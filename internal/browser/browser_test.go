@@ -0,0 +1,127 @@
+package browser
+
+import (
+	"bear_cli/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Stand-ins for the console login forms, with the fields and buttons the login steps look for.
+const (
+	awsLoginPage = `<!DOCTYPE html>
+<form>
+  <input name="username">
+  <input name="password" type="password">
+</form>`
+
+	gcpLoginPage = `<!DOCTYPE html>
+<input type="email">
+<div id="identifierNext"><button onclick="document.getElementById('pw').style.display = 'block'">Next</button></div>
+<div id="pw" style="display: none">
+  <input type="password" name="Passwd">
+  <div id="passwordNext"><button onclick="document.body.dataset.submitted = 'yes'">Next</button></div>
+</div>`
+)
+
+func findChrome(t *testing.T) string {
+	t.Helper()
+
+	for _, name := range []string{"google-chrome", "google-chrome-stable", "chromium", "chromium-browser", "chrome", "headless-shell"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	t.Skip("Chrome is not installed")
+	return ""
+}
+
+func TestLoginTasksHeadless(t *testing.T) {
+	chrome := findChrome(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/aws", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(awsLoginPage))
+	})
+	mux.HandleFunc("/gcp", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(gcpLoginPage))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		website       Website
+		path          string
+		usernameSel   string
+		passwordSel   string
+		wantSubmitted string
+	}{
+		{website: AWSConsole, path: "/aws", usernameSel: `input[name="username"]`, passwordSel: `input[name="password"]`},
+		{website: GCPConsole, path: "/gcp", usernameSel: `input[type="email"]`, passwordSel: `input[name="Passwd"]`, wantSubmitted: "yes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.website), func(t *testing.T) {
+			tasks, err := loginTasks(tt.website, server.URL+tt.path, "cloud_user", "p@ss word")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Containers often run as root, where Chrome refuses to start without --no-sandbox
+			allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), append(chromedp.DefaultExecAllocatorOptions[:],
+				chromedp.ExecPath(chrome),
+				chromedp.NoSandbox,
+			)...)
+			defer cancelAlloc()
+			ctx, cancel := chromedp.NewContext(allocCtx)
+			defer cancel()
+			ctx, cancelTimeout := context.WithTimeout(ctx, 30*time.Second)
+			defer cancelTimeout()
+
+			var username, password, submitted string
+			err = chromedp.Run(ctx, tasks,
+				chromedp.Value(tt.usernameSel, &username, chromedp.ByQuery),
+				chromedp.Value(tt.passwordSel, &password, chromedp.ByQuery),
+				chromedp.Evaluate(`document.body.dataset.submitted || ""`, &submitted),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if username != "cloud_user" || password != "p@ss word" {
+				t.Errorf("form filled with %q / %q", username, password)
+			}
+			if submitted != tt.wantSubmitted {
+				t.Errorf("submitted = %q, want %q", submitted, tt.wantSubmitted)
+			}
+		})
+	}
+}
+
+func TestLoginTasksUnknownWebsite(t *testing.T) {
+	if _, err := loginTasks("https://example.com", "", "user", "pass"); err == nil {
+		t.Error("expected an error for a website without a login flow")
+	}
+}
+
+func TestWebsiteForProvider(t *testing.T) {
+	tests := map[string]Website{
+		models.ProviderAWS:   AWSConsole,
+		models.ProviderAzure: AzurePortal,
+		models.ProviderGCP:   GCPConsole,
+	}
+	for provider, want := range tests {
+		if got, err := WebsiteForProvider(provider); err != nil || got != want {
+			t.Errorf("WebsiteForProvider(%q) = %q, %v, want %q", provider, got, err, want)
+		}
+	}
+
+	if _, err := WebsiteForProvider("oracle"); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
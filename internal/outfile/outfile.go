@@ -0,0 +1,198 @@
+package outfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+)
+
+type Mode int
+
+const (
+	// Replace the file
+	Overwrite Mode = iota
+	// Add the output after the existing content
+	Append
+	// Replace the variables the output defines and keep every other line
+	Merge
+)
+
+var dotenvKey = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_]*)\s*=`)
+
+// Raised instead of writing secrets to a file git could pick up.
+type UnignoredFileError struct {
+	Path     string
+	Worktree string
+}
+
+func (e *UnignoredFileError) Error() string {
+	return fmt.Sprintf("refusing to write %s: it is inside the git worktree %s and not gitignored", e.Path, e.Worktree)
+}
+
+// Writes content to path with 0600 permissions, atomically, unless git could commit the file.
+func Write(path string, content []byte, mode Mode) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	if err := checkGitIgnored(path); err != nil {
+		return err
+	}
+
+	if mode != Overwrite {
+		existing, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if mode == Append {
+			content = appendLines(existing, content)
+		} else {
+			content = mergeDotenv(existing, content)
+		}
+	}
+
+	return WriteAtomic(path, content)
+}
+
+// Returns the root of the git worktree containing path, if any.
+func findWorktree(path string) (string, bool) {
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return "", false
+		}
+	}
+}
+
+// Fails when path could not be written by Write, so callers can check before doing any work.
+func Check(path string) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	return checkGitIgnored(path)
+}
+
+func checkGitIgnored(path string) error {
+	worktree, ok := findWorktree(path)
+	if !ok {
+		return nil
+	}
+
+	// check-ignore exits 0 for an ignored path and 1 for one git would track
+	err := exec.Command("git", "-C", worktree, "check-ignore", "-q", "--", path).Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return &UnignoredFileError{Path: path, Worktree: worktree}
+	default:
+		return fmt.Errorf("cannot tell whether %s is gitignored: %w", path, err)
+	}
+}
+
+func appendLines(existing []byte, content []byte) []byte {
+	if len(existing) > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+		existing = append(existing, '\n')
+	}
+	return append(existing, content...)
+}
+
+// Replaces the lines defining the variables in content and appends the new ones,
+// keeping comments and unrelated variables of the existing file where they are.
+func mergeDotenv(existing []byte, content []byte) []byte {
+	updates := map[string]string{}
+	var order []string
+	for _, line := range splitLines(content) {
+		m := dotenvKey.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if _, ok := updates[m[1]]; !ok {
+			order = append(order, m[1])
+		}
+		updates[m[1]] = line
+	}
+
+	var merged []string
+	written := map[string]bool{}
+	for _, line := range splitLines(existing) {
+		m := dotenvKey.FindStringSubmatch(line)
+		if m == nil {
+			merged = append(merged, line)
+			continue
+		}
+		update, ok := updates[m[1]]
+		if !ok {
+			merged = append(merged, line)
+			continue
+		}
+		// A key defined twice keeps a single, updated definition
+		if !written[m[1]] {
+			merged = append(merged, update)
+			written[m[1]] = true
+		}
+	}
+	for _, key := range order {
+		if !written[key] {
+			merged = append(merged, updates[key])
+		}
+	}
+
+	var b bytes.Buffer
+	for _, line := range merged {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = string(bytes.TrimSuffix(line, []byte("\r")))
+	}
+	return result
+}
+
+// Writes content with mode 0600, creating the directory if needed. The content goes to a file next to
+// the target that is then renamed over it, so readers never see a truncated file.
+func WriteAtomic(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,141 @@
+package outfile
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeDotenv(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		content  string
+		want     string
+	}{
+		{
+			name:    "empty file",
+			content: "A='1'\nB='2'\n",
+			want:    "A='1'\nB='2'\n",
+		},
+		{
+			name:     "updates in place and keeps the rest",
+			existing: "# sandbox\nOTHER=x\nA='old'\n\nexport B=old\n",
+			content:  "A='1'\nB='2'\nC='3'\n",
+			want:     "# sandbox\nOTHER=x\nA='1'\n\nB='2'\nC='3'\n",
+		},
+		{
+			name:     "duplicate keys collapse into one",
+			existing: "A=1\nKEEP=y\nA=2\n",
+			content:  "A='new'\n",
+			want:     "A='new'\nKEEP=y\n",
+		},
+		{
+			name:     "CRLF and a missing final newline",
+			existing: "A=old\r\nKEEP=y",
+			content:  "A='new'\n",
+			want:     "A='new'\nKEEP=y\n",
+		},
+		{
+			name:     "export lines in the output",
+			existing: "A=old\n",
+			content:  "export A=\"new\"\n",
+			want:     "export A=\"new\"\n",
+		},
+		{
+			name:     "keys are matched exactly",
+			existing: "AB=1\n a = 2\n",
+			content:  "A='3'\n",
+			want:     "AB=1\n a = 2\nA='3'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(mergeDotenv([]byte(tt.existing), []byte(tt.content)))
+			if got != tt.want {
+				t.Errorf("mergeDotenv =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		mode     Mode
+		want     string
+	}{
+		{name: "overwrite", existing: "OLD=1\n", mode: Overwrite, want: "A='1'\n"},
+		{name: "append", existing: "OLD=1", mode: Append, want: "OLD=1\nA='1'\n"},
+		{name: "append to a new file", mode: Append, want: "A='1'\n"},
+		{name: "merge", existing: "A=0\nOLD=1\n", mode: Merge, want: "A='1'\nOLD=1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sub", ".env")
+			if tt.existing != "" {
+				if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := Write(path, []byte("A='1'\n"), tt.mode); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0600 {
+				t.Errorf("permissions = %o, want 600", perm)
+			}
+		})
+	}
+}
+
+func TestWriteInGitWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	worktree := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", worktree).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(worktree, ".gitignore"), []byte(".env\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Write(filepath.Join(worktree, ".env"), []byte("A='1'\n"), Overwrite); err != nil {
+		t.Errorf("ignored file: %v", err)
+	}
+
+	tracked := filepath.Join(worktree, "sandbox.env")
+	err := Write(tracked, []byte("A='1'\n"), Overwrite)
+	var unignored *UnignoredFileError
+	if !errors.As(err, &unignored) {
+		t.Fatalf("err = %v, want UnignoredFileError", err)
+	}
+	if _, err := os.Stat(tracked); !os.IsNotExist(err) {
+		t.Errorf("%s was written", tracked)
+	}
+	if err := Check(tracked); !errors.As(err, &unignored) {
+		t.Errorf("Check err = %v, want UnignoredFileError", err)
+	}
+}
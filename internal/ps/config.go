@@ -0,0 +1,55 @@
+package ps
+
+import (
+	"bear_cli/internal/secretstore"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// User settings read from ~/.config/bear/ps/config.json.
+type Config struct {
+	Store            string `json:"store,omitempty"`
+	AutoPurgeExpired bool   `json:"autoPurgeExpired,omitempty"`
+}
+
+func LoadConfig() (Config, error) {
+	var config Config
+
+	dir, err := psConfigDir()
+	if err != nil {
+		return config, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Picks the store used to write credentials: the explicit name if given, then the config, then plaintext file.
+func ResolveStore(name string) (secretstore.Store, error) {
+	if name == "" {
+		config, err := LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+		name = config.Store
+	}
+
+	if name == "" {
+		name = secretstore.File
+	}
+
+	return secretstore.New(name)
+}
@@ -0,0 +1,26 @@
+package ps
+
+// Files whose changes should make direnv reload the credential of a profile: the credential,
+// its metadata and, when the profile was not named explicitly, the current-profile pointer.
+func DirenvWatchFiles(profile string, followCurrent bool) ([]string, error) {
+	credPath, err := loadSandboxPath(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataPath, err := profileMetadataPath(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{credPath, metadataPath}
+	if followCurrent {
+		currentPath, err := currentProfilePath()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, currentPath)
+	}
+
+	return files, nil
+}
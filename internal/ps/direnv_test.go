@@ -0,0 +1,51 @@
+package ps
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDirenvWatchFiles(t *testing.T) {
+	dir := isolateConfig(t)
+
+	tests := []struct {
+		name          string
+		profile       string
+		followCurrent bool
+		want          []string
+		wantErr       bool
+	}{
+		{
+			name:    "named profile",
+			profile: "aws-lab",
+			want: []string{
+				filepath.Join(dir, "profiles", "aws-lab"+profileExt),
+				filepath.Join(dir, "profiles", "aws-lab"+profileMetadataExt),
+			},
+		},
+		{
+			name:          "current profile",
+			profile:       "default",
+			followCurrent: true,
+			want: []string{
+				filepath.Join(dir, "profiles", "default"+profileExt),
+				filepath.Join(dir, "profiles", "default"+profileMetadataExt),
+				filepath.Join(dir, "current"),
+			},
+		},
+		{name: "invalid profile", profile: "../x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DirenvWatchFiles(tt.profile, tt.followCurrent)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("DirenvWatchFiles = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package ps
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrNoHTMLSource = errors.New("no HTML source: pass --html-path or --clipboard")
+
+// Raised when the sandbox page cannot be read or parsed from its source.
+type HTMLSourceError struct {
+	Source string
+	Err    error
+}
+
+func (e *HTMLSourceError) Error() string {
+	return fmt.Sprintf("failed to read sandbox page from %s: %v", e.Source, e.Err)
+}
+
+func (e *HTMLSourceError) Unwrap() error {
+	return e.Err
+}
+
+// Raised when required fields of the sandbox page are missing or invalid.
+type MissingFieldError struct {
+	Provider string
+	Fields   []string
+}
+
+func (e *MissingFieldError) Error() string {
+	return fmt.Sprintf("%s sandbox page has missing or invalid required fields: %s (use --allow-partial to save anyway)", e.Provider, strings.Join(e.Fields, ", "))
+}
+
+// Raised when a stored credential belongs to a sandbox that no longer exists.
+type SandboxExpiredError struct {
+	Profile   string
+	ExpiredAt time.Time
+}
+
+func (e *SandboxExpiredError) Error() string {
+	return fmt.Sprintf("sandbox of profile %q expired at %s: run `bear ps create-cred --profile %s`", e.Profile, e.ExpiredAt.Local().Format(time.RFC3339), e.Profile)
+}
@@ -0,0 +1,125 @@
+package ps
+
+import (
+	"bear_cli/internal/secretstore"
+	"bear_cli/models"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// Runs the given command with the sandbox credential injected into its environment.
+// SIGTERM and SIGHUP received by bear are forwarded to the child, and the child's exit code is returned.
+func ExecWithCredential(cred models.SandboxCredential, scope models.CredentialScope, args []string) (int, error) {
+	if len(args) == 0 {
+		return 1, errors.New("no command given to exec")
+	}
+
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return 127, err
+	}
+
+	cred, cleanup, err := withKeyFile(cred)
+	if err != nil {
+		return 1, err
+	}
+	defer cleanup()
+
+	child := exec.Command(path, args[1:]...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	child.Env = credentialEnv(os.Environ(), cred, scope)
+
+	// The terminal already sends Ctrl-C and Ctrl-\ to the child, which shares bear's process group; forwarding
+	// them too would interrupt it twice. Catching them instead of ignoring them keeps the child's defaults intact.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt, syscall.SIGQUIT)
+	defer signal.Stop(interrupts)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGHUP)
+
+	if err := child.Start(); err != nil {
+		signal.Stop(signals)
+		return 1, err
+	}
+
+	go func() {
+		for sig := range signals {
+			child.Process.Signal(sig)
+		}
+	}()
+
+	err = child.Wait()
+	signal.Stop(signals)
+	close(signals)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// Mirror the shell convention of 128+n when the child was killed by a signal
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal()), nil
+		}
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 1, err
+	}
+
+	return 0, nil
+}
+
+// Returns base with the credential's variables set, replacing any value base already held for them.
+func credentialEnv(base []string, cred models.SandboxCredential, scope models.CredentialScope) []string {
+	vars := cred.ToScopedEnvMap(scope)
+
+	env := make([]string, 0, len(base)+len(vars))
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := vars[name]; !ok {
+			env = append(env, kv)
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(vars)) {
+		env = append(env, fmt.Sprintf("%s=%s", k, vars[k]))
+	}
+
+	return env
+}
+
+// GCP profiles in an encrypted store keep no key file on disk, so one is written for the child's
+// lifetime and shredded once it exits.
+func withKeyFile(cred models.SandboxCredential) (models.SandboxCredential, func(), error) {
+	gcpCred, ok := cred.(*models.PsGcpCredential)
+	if !ok || gcpCred.KeyFile != "" || gcpCred.ServiceAccountKey == "" {
+		return cred, func() {}, nil
+	}
+
+	f, err := os.CreateTemp("", "bear-gcp-*.json")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { secretstore.Shred(f.Name()) }
+
+	_, err = f.WriteString(gcpCred.ServiceAccountKey)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write service account key: %w", err)
+	}
+
+	withFile := *gcpCred
+	withFile.KeyFile = f.Name()
+	return &withFile, cleanup, nil
+}
@@ -0,0 +1,167 @@
+package ps
+
+import (
+	"bear_cli/models"
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCredentialEnv(t *testing.T) {
+	cred := &models.PsAwsCredential{
+		AWSCredential: models.AWSCredential{AccessKeyId: "AKIAEXAMPLE", SecretAccessKey: "secret", Region: "eu-west-1"},
+		User:          "cloud_user",
+		Password:      "hunter2",
+	}
+	base := []string{"PATH=/usr/bin", "AWS_REGION=us-east-1", "AWS_PASSWORD=stale"}
+
+	tests := []struct {
+		name  string
+		scope models.CredentialScope
+		want  []string
+	}{
+		{
+			name:  "full",
+			scope: models.ScopeFull,
+			want: []string{
+				"PATH=/usr/bin",
+				"AWS_ACCESS_KEY_ID=AKIAEXAMPLE",
+				"AWS_PASSWORD=hunter2",
+				"AWS_REGION=eu-west-1",
+				"AWS_SANDBOX_URL=",
+				"AWS_SECRET_ACCESS_KEY=secret",
+				"AWS_USERNAME=cloud_user",
+			},
+		},
+		{
+			name:  "terraform keeps variables outside the scope",
+			scope: models.ScopeTerraform,
+			want: []string{
+				"PATH=/usr/bin",
+				"AWS_PASSWORD=stale",
+				"AWS_ACCESS_KEY_ID=AKIAEXAMPLE",
+				"AWS_REGION=eu-west-1",
+				"AWS_SECRET_ACCESS_KEY=secret",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := credentialEnv(base, cred, tt.scope)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("credentialEnv() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+var signalNames = map[string]syscall.Signal{
+	"INT": syscall.SIGINT, "QUIT": syscall.SIGQUIT, "TERM": syscall.SIGTERM, "HUP": syscall.SIGHUP,
+}
+
+// Plays both processes of TestExecWithCredentialSignals: bear running ExecWithCredential, in its own process group,
+// and the child it starts, which counts the deliveries of the signal over a second.
+func TestExecSignalHelper(t *testing.T) {
+	if name := os.Getenv("BEAR_EXEC_SIGNAL_CHILD"); name != "" {
+		received := make(chan os.Signal, 16)
+		signal.Notify(received, signalNames[name])
+		fmt.Println("ready")
+
+		count := 0
+		for deadline := time.After(time.Second); ; {
+			select {
+			case <-received:
+				count++
+				continue
+			case <-deadline:
+			}
+			break
+		}
+		fmt.Printf("count=%d\n", count)
+		return
+	}
+
+	name := os.Getenv("BEAR_EXEC_SIGNAL_HELPER")
+	if name == "" {
+		t.Skip("only runs as a helper process of TestExecWithCredentialSignals")
+	}
+	os.Setenv("BEAR_EXEC_SIGNAL_CHILD", name)
+
+	code, err := ExecWithCredential(&models.PsAwsCredential{}, models.ScopeFull, []string{os.Args[0], "-test.run=^TestExecSignalHelper$"})
+	if err != nil || code != 0 {
+		fmt.Fprintf(os.Stderr, "exec: code %d, err %v\n", code, err)
+	}
+}
+
+func TestExecWithCredentialSignals(t *testing.T) {
+	tests := []struct {
+		name   string
+		signal string
+		target string
+		want   string
+	}{
+		// A terminal sends Ctrl-C and Ctrl-\ to the whole foreground process group, child included
+		{name: "SIGINT from the terminal", signal: "INT", target: "group", want: "count=1"},
+		{name: "SIGQUIT from the terminal", signal: "QUIT", target: "group", want: "count=1"},
+		// so bear must not pass them on as well
+		{name: "SIGINT sent to bear", signal: "INT", target: "bear", want: "count=0"},
+		{name: "SIGQUIT sent to bear", signal: "QUIT", target: "bear", want: "count=0"},
+		// kill and hangups reach bear alone and are forwarded
+		{name: "SIGTERM sent to bear", signal: "TERM", target: "bear", want: "count=1"},
+		{name: "SIGHUP sent to bear", signal: "HUP", target: "bear", want: "count=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			helper := exec.Command(os.Args[0], "-test.run=^TestExecSignalHelper$")
+			helper.Env = append(os.Environ(), "BEAR_EXEC_SIGNAL_HELPER="+tt.signal)
+			helper.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+			helper.Stderr = os.Stderr
+			stdout, err := helper.StdoutPipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := helper.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer helper.Process.Kill()
+
+			lines := bufio.NewScanner(stdout)
+			if !lines.Scan() || lines.Text() != "ready" {
+				t.Fatalf("child did not start: %q", lines.Text())
+			}
+
+			target := helper.Process.Pid
+			if tt.target == "group" {
+				target = -target
+			}
+			if err := syscall.Kill(target, signalNames[tt.signal]); err != nil {
+				t.Fatal(err)
+			}
+
+			done := make(chan string, 1)
+			go func() {
+				lines.Scan()
+				done <- lines.Text()
+			}()
+			select {
+			case out := <-done:
+				if out != tt.want {
+					t.Errorf("child printed %q, want %q", out, tt.want)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatal("child never reported")
+			}
+			helper.Wait()
+		})
+	}
+}
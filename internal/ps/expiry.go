@@ -0,0 +1,95 @@
+package ps
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	timeRemainingLabel = regexp.MustCompile(`(?i)time\s+(remaining|left)|expires?\s+in`)
+	clockDuration      = regexp.MustCompile(`\b(\d{1,2}):(\d{2})(?::(\d{2}))?\b`)
+	hourDuration       = regexp.MustCompile(`(?i)(\d+)\s*(?:h|hrs?|hours?)\b`)
+	minuteDuration     = regexp.MustCompile(`(?i)(\d+)\s*(?:m|mins?|minutes?)\b`)
+	secondDuration     = regexp.MustCompile(`(?i)(\d+)\s*(?:s|secs?|seconds?)\b`)
+)
+
+// Parses the sandbox countdown shown by PluralSight, e.g. "3:59:10", "59:10" or "3 hours 59 minutes".
+func ParseTimeRemaining(text string) (time.Duration, bool) {
+	if m := clockDuration.FindStringSubmatch(text); m != nil {
+		first, _ := strconv.Atoi(m[1])
+		second, _ := strconv.Atoi(m[2])
+		if m[3] == "" {
+			// Without a third group the countdown is minutes:seconds
+			return time.Duration(first)*time.Minute + time.Duration(second)*time.Second, true
+		}
+		third, _ := strconv.Atoi(m[3])
+		return time.Duration(first)*time.Hour + time.Duration(second)*time.Minute + time.Duration(third)*time.Second, true
+	}
+
+	var total time.Duration
+	found := false
+	for _, unit := range []struct {
+		re   *regexp.Regexp
+		size time.Duration
+	}{
+		{hourDuration, time.Hour},
+		{minuteDuration, time.Minute},
+		{secondDuration, time.Second},
+	} {
+		if m := unit.re.FindStringSubmatch(text); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			total += time.Duration(n) * unit.size
+			found = true
+		}
+	}
+
+	return total, found
+}
+
+// Looks for a "Time remaining"-style label on the sandbox page and parses the countdown next to it.
+func extractTimeRemaining(doc *goquery.Document) (time.Duration, bool) {
+	var remaining time.Duration
+	found := false
+
+	doc.Find("body *").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if s.Children().Length() > 0 {
+			return true
+		}
+
+		label := strings.TrimSpace(s.Text())
+		if !timeRemainingLabel.MatchString(label) {
+			return true
+		}
+
+		// Join the parent's children with spaces, as Text() would run "Time left" and "45:00" together
+		siblings := strings.Join(s.Parent().Contents().Map(func(_ int, c *goquery.Selection) string {
+			return c.Text()
+		}), " ")
+		for _, text := range []string{label, siblings} {
+			if d, ok := ParseTimeRemaining(text); ok {
+				remaining, found = d, true
+				return false
+			}
+		}
+		return true
+	})
+
+	return remaining, found
+}
+
+// Works out when the sandbox expires, preferring an explicit TTL over the countdown found on the page.
+func sandboxExpiration(ttl time.Duration, extracted map[string]string) time.Time {
+	if ttl > 0 {
+		return time.Now().Add(ttl).Truncate(time.Second)
+	}
+
+	if remaining, err := time.ParseDuration(extracted["TIME_REMAINING"]); err == nil && remaining > 0 {
+		return time.Now().Add(remaining).Truncate(time.Second)
+	}
+
+	return time.Time{}
+}
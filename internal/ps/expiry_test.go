@@ -0,0 +1,104 @@
+package ps
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseTimeRemaining(t *testing.T) {
+	tests := []struct {
+		text   string
+		want   time.Duration
+		wantOK bool
+	}{
+		{text: "3:59:10", want: 3*time.Hour + 59*time.Minute + 10*time.Second, wantOK: true},
+		{text: "59:10", want: 59*time.Minute + 10*time.Second, wantOK: true},
+		{text: "Time remaining: 0:05", want: 5 * time.Second, wantOK: true},
+		{text: "1h 5m", want: time.Hour + 5*time.Minute, wantOK: true},
+		{text: "45m", want: 45 * time.Minute, wantOK: true},
+		{text: "2 hours", want: 2 * time.Hour, wantOK: true},
+		{text: "1 hr 30 mins", want: 90 * time.Minute, wantOK: true},
+		{text: "3 hours 59 minutes 10 seconds", want: 3*time.Hour + 59*time.Minute + 10*time.Second, wantOK: true},
+		{text: "Expires in 20 min", want: 20 * time.Minute, wantOK: true},
+		{text: "soon"},
+		{text: ""},
+		{text: "Time remaining: --"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, ok := ParseTimeRemaining(tt.text)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("ParseTimeRemaining(%q) = %s, %t, want %s, %t", tt.text, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestExtractTimeRemaining(t *testing.T) {
+	tests := []struct {
+		name   string
+		html   string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "value in the label", html: `<div><span>Time remaining: 1h 5m</span></div>`, want: time.Hour + 5*time.Minute, wantOK: true},
+		{name: "value next to the label", html: `<div><span>Time left</span><span>45:00</span></div>`, want: 45 * time.Minute, wantOK: true},
+		{name: "no label", html: `<div><span>3:59:10</span></div>`},
+		{name: "label without a countdown", html: `<div><span>Time remaining</span><span>unknown</span></div>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := extractTimeRemaining(doc)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("extractTimeRemaining = %s, %t, want %s, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSandboxExpiration(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       time.Duration
+		remaining string
+		want      time.Duration
+	}{
+		{name: "scraped countdown", remaining: "3h59m10s", want: 3*time.Hour + 59*time.Minute + 10*time.Second},
+		{name: "--ttl wins over the countdown", ttl: time.Hour, remaining: "3h59m10s", want: time.Hour},
+		{name: "--ttl without a countdown", ttl: 30 * time.Minute, want: 30 * time.Minute},
+		{name: "unknown expiry"},
+		{name: "unparseable countdown", remaining: "soon"},
+		{name: "countdown already over", remaining: "0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extracted := map[string]string{}
+			if tt.remaining != "" {
+				extracted["TIME_REMAINING"] = tt.remaining
+			}
+
+			before := time.Now().Truncate(time.Second)
+			got := sandboxExpiration(tt.ttl, extracted)
+
+			if tt.want == 0 {
+				if !got.IsZero() {
+					t.Errorf("expiration = %s, want unknown", got)
+				}
+				return
+			}
+			if d := got.Sub(before); d < tt.want || d > tt.want+2*time.Second {
+				t.Errorf("expiration is %s from now, want %s", d, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package ps
+
+import (
+	"bear_cli/internal/awsapi"
+	"bear_cli/internal/browser"
+	"bear_cli/models"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Logs in to the console of the provider the credential belongs to.
+func LoginWithCredential(cred models.SandboxCredential, opts browser.Options) error {
+	website, err := browser.WebsiteForProvider(cred.Provider())
+	if err != nil {
+		return err
+	}
+
+	switch cred.Provider() {
+	case models.ProviderAWS:
+		awsCred, err := loadAWSSandboxCredential(cred)
+		if err != nil {
+			return err
+		}
+		return LoginAWSConsole(awsCred, opts)
+	case models.ProviderAzure:
+		azureCred, err := loadAzureSandboxCredential(cred)
+		if err != nil {
+			return err
+		}
+		return loginWithPassword(website, azureCred.User, azureCred.Password, azureCred.SandboxURL, opts)
+	case models.ProviderGCP:
+		gcpCred, err := loadGCPSandboxCredential(cred)
+		if err != nil {
+			return err
+		}
+		return loginWithPassword(website, gcpCred.User, gcpCred.Password, gcpCred.SandboxURL, opts)
+	default:
+		return fmt.Errorf("no login flow for %s credentials", cred.Provider())
+	}
+}
+
+func loginWithPassword(website browser.Website, user, password, url string, opts browser.Options) error {
+	if user == "" || password == "" {
+		return fmt.Errorf("sandbox credential missing username or password")
+	}
+
+	return browser.LoginInBrowser(user, password, website, url, opts)
+}
+
+// Builds a credential from the portal login variables that get-cred exports, so login-by-cred works without a
+// stored profile. Returns nil when no provider's username and password are set.
+func SandboxCredentialFromEnv() (models.SandboxCredential, error) {
+	var creds []models.SandboxCredential
+
+	if user, password := os.Getenv("AWS_USERNAME"), os.Getenv("AWS_PASSWORD"); user != "" && password != "" {
+		cred := &models.PsAwsCredential{SandboxURL: os.Getenv("AWS_SANDBOX_URL"), User: user, Password: password}
+		// The access keys are optional, but let the console be opened through federation
+		cred.AccessKeyId = os.Getenv("AWS_ACCESS_KEY_ID")
+		cred.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		cred.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		cred.Region = os.Getenv("AWS_REGION")
+		creds = append(creds, cred)
+	}
+	if user, password := os.Getenv("ARM_USERNAME"), os.Getenv("ARM_PASSWORD"); user != "" && password != "" {
+		creds = append(creds, &models.PsAzureCredential{SandboxURL: os.Getenv("ARM_SANDBOX_URL"), User: user, Password: password})
+	}
+	if user, password := os.Getenv("GCP_USERNAME"), os.Getenv("GCP_PASSWORD"); user != "" && password != "" {
+		creds = append(creds, &models.PsGcpCredential{SandboxURL: os.Getenv("GCP_SANDBOX_URL"), User: user, Password: password})
+	}
+
+	switch len(creds) {
+	case 0:
+		return nil, nil
+	case 1:
+		return creds[0], nil
+	default:
+		providers := make([]string, len(creds))
+		for i, c := range creds {
+			providers[i] = c.Provider()
+		}
+		return nil, fmt.Errorf("the environment holds sandbox logins for %s: unset all but one", strings.Join(providers, ", "))
+	}
+}
+
+// Returns a console sign-in URL for the sandbox's access keys, which is valid for 15 minutes.
+func AWSConsoleSigninURL(cred *models.PsAwsCredential) (string, error) {
+	if cred.AccessKeyId == "" || cred.SecretAccessKey == "" {
+		return "", fmt.Errorf("sandbox credential missing access keys")
+	}
+
+	return awsapi.NewFederationClient().ConsoleSigninURL(cred.AWSCredential, cred.User)
+}
+
+// Opens the AWS console signed in through the federation endpoint. When the sandbox user may not federate,
+// it falls back to filling in the sandbox login form.
+func LoginAWSConsole(cred *models.PsAwsCredential, opts browser.Options) error {
+	if cred.AccessKeyId != "" && cred.SecretAccessKey != "" {
+		url, err := AWSConsoleSigninURL(cred)
+		if err == nil {
+			return browser.OpenInBrowser(url, opts)
+		}
+		if cred.User == "" || cred.Password == "" {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Could not sign in through federation (%v), filling in the sandbox login form instead\n", err)
+	}
+
+	return loginWithPassword(browser.AWSConsole, cred.User, cred.Password, cred.SandboxURL, opts)
+}
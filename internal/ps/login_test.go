@@ -0,0 +1,61 @@
+package ps
+
+import (
+	"bear_cli/models"
+	"testing"
+)
+
+func TestSandboxCredentialFromEnv(t *testing.T) {
+	vars := []string{
+		"AWS_USERNAME", "AWS_PASSWORD", "AWS_SANDBOX_URL", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_REGION",
+		"ARM_USERNAME", "ARM_PASSWORD", "ARM_SANDBOX_URL",
+		"GCP_USERNAME", "GCP_PASSWORD", "GCP_SANDBOX_URL",
+	}
+
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantProvider string
+		wantErr      bool
+	}{
+		{name: "nothing exported"},
+		{name: "username without password", env: map[string]string{"ARM_USERNAME": "user"}},
+		{
+			name:         "AWS",
+			env:          map[string]string{"AWS_USERNAME": "user", "AWS_PASSWORD": "pass", "AWS_ACCESS_KEY_ID": "AKIAEXAMPLE"},
+			wantProvider: models.ProviderAWS,
+		},
+		{name: "Azure", env: map[string]string{"ARM_USERNAME": "user", "ARM_PASSWORD": "pass"}, wantProvider: models.ProviderAzure},
+		{name: "Google Cloud", env: map[string]string{"GCP_USERNAME": "user", "GCP_PASSWORD": "pass"}, wantProvider: models.ProviderGCP},
+		{
+			name:    "two providers",
+			env:     map[string]string{"ARM_USERNAME": "user", "ARM_PASSWORD": "pass", "GCP_USERNAME": "user", "GCP_PASSWORD": "pass"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, v := range vars {
+				t.Setenv(v, tt.env[v])
+			}
+
+			cred, err := SandboxCredentialFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantProvider == "" {
+				if cred != nil {
+					t.Errorf("cred = %#v, want none", cred)
+				}
+				return
+			}
+			if cred == nil || cred.Provider() != tt.wantProvider {
+				t.Fatalf("cred = %#v, want a %s credential", cred, tt.wantProvider)
+			}
+			if aws, ok := cred.(*models.PsAwsCredential); ok && aws.AccessKeyId != "AKIAEXAMPLE" {
+				t.Errorf("access key = %q, want the exported one", aws.AccessKeyId)
+			}
+		})
+	}
+}
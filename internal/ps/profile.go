@@ -0,0 +1,470 @@
+package ps
+
+import (
+	"bear_cli/internal/secretstore"
+	"bear_cli/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const DefaultProfile = "default"
+
+const profileExt = ".json"
+
+const profileMetadataExt = ".meta"
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+func psConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".config", "bear", "ps"), nil
+}
+
+func profilesDir() (string, error) {
+	dir, err := psConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "profiles"), nil
+}
+
+func currentProfilePath() (string, error) {
+	dir, err := psConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "current"), nil
+}
+
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	return nil
+}
+
+// Moves the single sandbox_cred.json used before profiles existed into the default profile.
+func migrateLegacySandbox() error {
+	dir, err := psConfigDir()
+	if err != nil {
+		return err
+	}
+
+	legacyPath := filepath.Join(dir, "sandbox_cred.json")
+	if _, err := os.Stat(legacyPath); err != nil {
+		return nil
+	}
+
+	profilePath, err := profileFilePath(DefaultProfile)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(profilePath); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(profilePath), 0700); err != nil {
+		return err
+	}
+
+	return os.Rename(legacyPath, profilePath)
+}
+
+func profileFilePath(profile string) (string, error) {
+	dir, err := profilesDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, profile+profileExt), nil
+}
+
+func profileMetadataPath(profile string) (string, error) {
+	dir, err := profilesDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, profile+profileMetadataExt), nil
+}
+
+// The service account key of a GCP profile, kept outside the profiles directory so it is never listed as a profile.
+func gcpKeyFilePath(profile string) (string, error) {
+	dir, err := psConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "keys", profile+"-gcp.json"), nil
+}
+
+// The isolated Azure CLI config directory of a profile, used as AZURE_CONFIG_DIR.
+func AzureConfigDirPath(profile string) (string, error) {
+	dir, err := psConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "azure", profile), nil
+}
+
+// The Chrome user-data-dir kept for a profile's --persistent-browser logins.
+func BrowserProfileDir(profile string) (string, error) {
+	if err := ValidateProfileName(profile); err != nil {
+		return "", err
+	}
+
+	dir, err := browserProfilesDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, profile), nil
+}
+
+func browserProfilesDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".config", "bear", "browser"), nil
+}
+
+// Wipes the browser profiles whose sandbox has expired or whose credential is gone, returning their names.
+// A profile Chrome still has open is left for a later run, and one that fails does not stop the others.
+func PurgeStaleBrowserProfiles() ([]string, error) {
+	dir, err := browserProfilesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	wiped := []string{}
+	var errs []error
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() || ValidateProfileName(name) != nil {
+			continue
+		}
+
+		stale, err := browserProfileStale(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("browser profile %s: %w", name, err))
+			continue
+		}
+		if !stale || browserProfileInUse(filepath.Join(dir, name)) {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			errs = append(errs, fmt.Errorf("browser profile %s: %w", name, err))
+			continue
+		}
+		wiped = append(wiped, name)
+	}
+
+	return wiped, errors.Join(errs...)
+}
+
+func browserProfileStale(profile string) (bool, error) {
+	exists, err := ProfileExists(profile)
+	if err != nil || !exists {
+		return !exists, err
+	}
+
+	metadata, _, err := LoadProfileMetadata(profile)
+	if err != nil {
+		return false, err
+	}
+	return metadata.IsExpired(), nil
+}
+
+// Chrome holds a SingletonLock in the user-data-dir while it runs; wiping the directory under it would break it.
+func browserProfileInUse(dir string) bool {
+	_, err := os.Lstat(filepath.Join(dir, "SingletonLock"))
+	return err == nil
+}
+
+// Non-secret facts about a profile, kept next to the credential so they can be read
+// without unlocking the credential store.
+type ProfileMetadata struct {
+	models.SandboxLifetime
+
+	Provider string `json:"provider"`
+	// Sections written to ~/.aws/credentials and ~/.aws/config, removed again on purge
+	AWSProfiles []string `json:"awsProfiles,omitempty"`
+	// Azure CLI config directories holding the service principal, removed again on purge
+	AzureConfigDirs []string `json:"azureConfigDirs,omitempty"`
+}
+
+// Records the credential's provider and expiry, keeping what was recorded about its outputs.
+func saveProfileMetadata(profile string, cred models.SandboxCredential) error {
+	metadata, _, err := LoadProfileMetadata(profile)
+	if err != nil {
+		return err
+	}
+
+	metadata.Provider = cred.Provider()
+	metadata.Expiration = cred.ExpiresAt()
+
+	return writeProfileMetadata(profile, metadata)
+}
+
+func writeProfileMetadata(profile string, metadata ProfileMetadata) error {
+	path, err := profileMetadataPath(profile)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+// Returns the metadata of a profile, or false for profiles saved before metadata was recorded.
+func LoadProfileMetadata(profile string) (ProfileMetadata, bool, error) {
+	var metadata ProfileMetadata
+
+	path, err := profileMetadataPath(profile)
+	if err != nil {
+		return metadata, false, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return metadata, false, nil
+	}
+	if err != nil {
+		return metadata, false, err
+	}
+
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return metadata, false, err
+	}
+
+	return metadata, true, nil
+}
+
+func removeProfileMetadata(profile string) error {
+	path, err := profileMetadataPath(profile)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Returns the profile to operate on: the explicit name if given, otherwise the current default.
+func ResolveProfile(name string) (string, error) {
+	if name != "" {
+		return name, ValidateProfileName(name)
+	}
+
+	return CurrentProfile()
+}
+
+func CurrentProfile() (string, error) {
+	path, err := currentProfilePath()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultProfile, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.TrimSpace(string(data))
+	if name == "" {
+		return DefaultProfile, nil
+	}
+
+	return name, ValidateProfileName(name)
+}
+
+func ProfileExists(profile string) (bool, error) {
+	path, err := loadSandboxPath(profile)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func ListProfiles() ([]string, error) {
+	if err := migrateLegacySandbox(); err != nil {
+		return nil, err
+	}
+
+	dir, err := profilesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := []string{}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != profileExt {
+			continue
+		}
+		profiles = append(profiles, strings.TrimSuffix(e.Name(), profileExt))
+	}
+	sort.Strings(profiles)
+
+	return profiles, nil
+}
+
+func UseProfile(profile string) error {
+	if err := ValidateProfileName(profile); err != nil {
+		return err
+	}
+
+	exists, err := ProfileExists(profile)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("profile %q does not exist", profile)
+	}
+
+	path, err := currentProfilePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, []byte(profile+"\n"), 0600)
+}
+
+func DeleteProfile(profile string) error {
+	if err := ValidateProfileName(profile); err != nil {
+		return err
+	}
+
+	exists, err := ProfileExists(profile)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("profile %q does not exist", profile)
+	}
+
+	if err := PurgeSandboxCredential(profile); err != nil {
+		return err
+	}
+
+	// Fall back to the default profile when the current one is deleted
+	current, err := CurrentProfile()
+	if err == nil && current == profile {
+		path, err := currentProfilePath()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Purges every profile whose sandbox is known to have expired and returns their names. A profile that
+// cannot be checked or purged doesn't stop the others; the errors are returned together.
+func PurgeExpiredCredentials() ([]string, error) {
+	profiles, err := ListProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	purged := []string{}
+	var errs []error
+	for _, p := range profiles {
+		metadata, ok, err := LoadProfileMetadata(p)
+		if err == nil && !ok {
+			metadata, ok, err = backfillProfileMetadata(p)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("profile %s: %w", p, err))
+			continue
+		}
+		if !ok || !metadata.IsExpired() {
+			continue
+		}
+
+		if err := PurgeSandboxCredential(p); err != nil {
+			errs = append(errs, fmt.Errorf("profile %s: %w", p, err))
+			continue
+		}
+		purged = append(purged, p)
+	}
+
+	return purged, errors.Join(errs...)
+}
+
+// Writes the metadata of a profile saved before metadata existed, taking the expiry from the credential.
+// Only plaintext credentials are read, as the others would ask for a passphrase or the keyring on every run.
+func backfillProfileMetadata(profile string) (ProfileMetadata, bool, error) {
+	path, err := profileFilePath(profile)
+	if err != nil {
+		return ProfileMetadata{}, false, err
+	}
+
+	store, err := secretstore.Detect(path)
+	if err != nil || store.Name() != secretstore.File {
+		return ProfileMetadata{}, false, err
+	}
+
+	cred, err := loadSandboxCredentialFile(path, profile)
+	if err != nil {
+		return ProfileMetadata{}, false, err
+	}
+	if err := saveProfileMetadata(profile, cred); err != nil {
+		return ProfileMetadata{}, false, err
+	}
+
+	return LoadProfileMetadata(profile)
+}
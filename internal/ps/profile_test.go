@@ -0,0 +1,103 @@
+package ps
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeProfileFile(t *testing.T, name, content string) {
+	t.Helper()
+
+	dir, err := profilesDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPurgeExpiredCredentials(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// Saved before profiles had metadata: the expiry only lives in the credential
+	writeProfileFile(t, "old.json", `{"provider":"aws","credential":{"accessKeyId":"AKIAEXAMPLE","expiration":"2020-01-01T00:00:00Z"}}`)
+	writeProfileFile(t, "alive.json", `{"provider":"aws","credential":{"accessKeyId":"AKIAEXAMPLE","expiration":"2999-01-01T00:00:00Z"}}`)
+	writeProfileFile(t, "broken.json", `{"provider":"aws","credential":{}}`)
+	writeProfileFile(t, "broken.meta", `{not json`)
+
+	purged, err := PurgeExpiredCredentials()
+	if err == nil {
+		t.Error("the corrupt metadata was not reported")
+	}
+	if !slices.Equal(purged, []string{"old"}) {
+		t.Errorf("purged %v, want [old]", purged)
+	}
+
+	profiles, err := ListProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(profiles, []string{"alive", "broken"}) {
+		t.Errorf("profiles left = %v, want [alive broken]", profiles)
+	}
+
+	metadata, ok, err := LoadProfileMetadata("alive")
+	if err != nil || !ok {
+		t.Fatalf("metadata of alive was not written: %v", err)
+	}
+	if metadata.Expiration.Year() != 2999 {
+		t.Errorf("expiration = %s, want 2999", metadata.Expiration)
+	}
+}
+
+func TestPurgeStaleBrowserProfiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	writeProfileFile(t, "alive.json", `{"provider":"aws","credential":{"accessKeyId":"AKIAEXAMPLE"}}`)
+	writeProfileFile(t, "alive.meta", `{"expiration":"2999-01-01T00:00:00Z"}`)
+	writeProfileFile(t, "expired.json", `{"provider":"aws","credential":{"accessKeyId":"AKIAEXAMPLE"}}`)
+	writeProfileFile(t, "expired.meta", `{"expiration":"2020-01-01T00:00:00Z"}`)
+	writeProfileFile(t, "broken.json", `{"provider":"aws","credential":{}}`)
+	writeProfileFile(t, "broken.meta", `{not json`)
+
+	dir, err := browserProfilesDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"alive", "expired", "broken", "gone", "running"} {
+		if err := os.MkdirAll(filepath.Join(dir, p, "Default"), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The credential of running is gone, but Chrome still has its profile open
+	if err := os.Symlink("host-1234", filepath.Join(dir, "running", "SingletonLock")); err != nil {
+		t.Fatal(err)
+	}
+
+	wiped, err := PurgeStaleBrowserProfiles()
+	if err == nil {
+		t.Error("the corrupt metadata was not reported")
+	}
+	slices.Sort(wiped)
+	if !slices.Equal(wiped, []string{"expired", "gone"}) {
+		t.Errorf("wiped %v, want [expired gone]", wiped)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var left []string
+	for _, e := range entries {
+		left = append(left, e.Name())
+	}
+	if !slices.Equal(left, []string{"alive", "broken", "running"}) {
+		t.Errorf("browser profiles left = %v, want [alive broken running]", left)
+	}
+}
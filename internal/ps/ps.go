@@ -2,204 +2,315 @@ package ps
 
 import (
 	"bear_cli/internal/armapi"
-	"bear_cli/internal/browser"
+	"bear_cli/internal/awscli"
+	"bear_cli/internal/azcli"
+	"bear_cli/internal/secretstore"
 	"bear_cli/models"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
-	"log"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/atotto/clipboard"
 )
 
-func RunExtractor(useClipboard bool, htmlPath string, cloudProvider models.CloudProvider) map[string]string {
-	var htmlContent string
-	var err error
-
-	if useClipboard {
-		htmlContent, err = clipboard.ReadAll()
-		if err != nil {
-			log.Fatal("Failed to read clipboard:", err)
-		}
-	} else if htmlPath != "" {
-		data, err := os.ReadFile(htmlPath)
-		if err != nil {
-			log.Fatal("Failed to read file:", err)
-		}
-		htmlContent = string(data)
-	} else {
-		log.Fatal("Either --file or --clipboard must be provided")
+func RunExtractor(useClipboard bool, htmlPath string, cloudProvider models.CloudProvider) (map[string]string, error) {
+	htmlContent, err := readHTMLSource(useClipboard, htmlPath)
+	if err != nil {
+		return nil, err
 	}
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
 	if err != nil {
-		log.Fatal("Failed to parse HTML:", err)
+		return nil, &HTMLSourceError{Source: "HTML parser", Err: err}
 	}
 
-	var KeyMapping = make(map[string]string)
-
-	switch cloudProvider {
-	case models.AWS:
-		KeyMapping = map[string]string{
-			"Username":          "USERNAME",
-			"Password":          "PASSWORD",
-			"Access Key Id":     "ACCESS_KEY_ID",
-			"Secret Access Key": "SECRET_ACCESS_KEY",
-		}
-	case models.Azure:
-		KeyMapping = map[string]string{
-			"Username":              "USER",
-			"Password":              "PASSWORD",
-			"Application Client ID": "CLIENT_ID",
-			"Secret":                "CLIENT_SECRET",
-		}
+	rules, err := LoadExtractionRules()
+	if err != nil {
+		return nil, err
 	}
 
-	creds := make(map[string]string)
+	providerRules, err := rulesFor(rules, cloudProvider)
+	if err != nil {
+		return nil, err
+	}
 
-	// Extract clientId, clientSecret, username and password
-	doc.Find("input").Each(func(i int, s *goquery.Selection) {
-		id, exists := s.Attr("id")
-		if !exists {
-			return
-		}
+	creds := providerRules.apply(doc)
 
-		value, _ := s.Attr("value")
-		if mappedKey, ok := KeyMapping[id]; ok {
-			creds[mappedKey] = value
-		}
-	})
-	doc.Find("strong").Each(func(i int, s *goquery.Selection) {
-		if strings.TrimSpace(s.Text()) == "Sandbox URL" {
-			span := s.Parent().Find("span").First()
-			url := strings.TrimSpace(span.Text())
-			creds["SANDBOX_URL"] = url
-
-			re := regexp.MustCompile(`[?&]region=([^&]+)`)
-			matches := re.FindStringSubmatch(url)
-			if len(matches) > 1 {
-				creds["REGION"] = matches[1]
-			}
+	if remaining, ok := extractTimeRemaining(doc); ok {
+		creds["TIME_REMAINING"] = remaining.String()
+	}
 
-			if cloudProvider == models.Azure {
-				parts := strings.SplitN(url, "#", 2)
-				if len(parts) < 2 {
-					return
-				}
-				fragment := parts[1]
-				if strings.HasPrefix(fragment, "@") {
-					endIdx := strings.Index(fragment, "/")
-					if endIdx > 0 {
-						creds["TENANT_NAME"] = fragment[1:endIdx]
-					}
-				}
-
-				fragParts := strings.Split(fragment, "/")
-				for i, p := range fragParts {
-					if p == "subscriptions" && i+1 < len(fragParts) {
-						creds["SUBSCRIPTION_ID"] = fragParts[i+1]
-					}
-					if p == "resourceGroups" && i+1 < len(fragParts) {
-						creds["RESOURCE_GROUP"] = fragParts[i+1]
-					}
-				}
-			}
-		}
-	})
-	return creds
+	return creds, nil
 }
 
-func loadSandboxPath() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
+func loadSandboxPath(profile string) (string, error) {
+	if err := ValidateProfileName(profile); err != nil {
+		return "", err
+	}
+
+	if err := migrateLegacySandbox(); err != nil {
 		return "", err
 	}
 
-	path := filepath.Join(home, ".config", "bear", "ps", "sandbox_cred.json")
-	return path, nil
+	return profileFilePath(profile)
 }
 
-func SaveSandboxCredential(cred models.SandboxCredential) error {
-	path, err := loadSandboxPath()
+// On-disk envelope that records which provider the credential payload belongs to.
+type storedSandboxCredential struct {
+	Provider   string          `json:"provider"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+func SaveSandboxCredential(cred models.SandboxCredential, profile string, store secretstore.Store) error {
+	path, err := loadSandboxPath(profile)
 	if err != nil {
 		return err
 	}
 
-	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+	payload, err := json.Marshal(cred)
+	if err != nil {
 		return err
 	}
 
-	data, err := json.MarshalIndent(cred, "", "  ")
+	data, err := json.MarshalIndent(storedSandboxCredential{
+		Provider:   cred.Provider(),
+		Credential: payload,
+	}, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0600)
+	if previous, err := secretstore.Detect(path); err == nil && previous.Name() != store.Name() {
+		if err := moveToStore(profile, path, data, previous, store); err != nil {
+			return err
+		}
+	} else if err := store.Save(profile, path, data); err != nil {
+		return err
+	}
+
+	return saveProfileMetadata(profile, cred)
 }
 
-func LoadSandboxCredential() (*models.PsAzureCredential, error) {
-	path, err := loadSandboxPath()
+// Saves the credential to a store other than the one holding it now. Both stores keep their file at the
+// same path, so the new one writes next to it and only replaces it once the save succeeded; the previous
+// payload is purged last so a failing backend never loses the stored credential.
+func moveToStore(profile, path string, data []byte, previous, store secretstore.Store) error {
+	next, old := path+".new", path+".old"
+
+	if err := store.Save(profile, next, data); err != nil {
+		secretstore.Shred(next)
+		return err
+	}
+
+	if err := os.Rename(path, old); err != nil {
+		secretstore.Shred(next)
+		return err
+	}
+	if err := os.Rename(next, path); err != nil {
+		os.Rename(old, path)
+		secretstore.Shred(next)
+		return err
+	}
+
+	if err := previous.Purge(profile, old); err != nil {
+		return fmt.Errorf("saved credential to the %s store, but failed to purge it from the %s store: %w", store.Name(), previous.Name(), err)
+	}
+
+	return nil
+}
+
+func LoadSandboxCredential(profile string) (models.SandboxCredential, error) {
+	path, err := loadSandboxPath(profile)
 	if err != nil {
 		return nil, err
 	}
 
-	data, err := os.ReadFile(path)
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("no credential stored for profile %q: run `bear ps create-cred --profile %s`", profile, profile)
+	}
+
+	return loadSandboxCredentialFile(path, profile)
+}
+
+func loadSandboxCredentialFile(path string, profile string) (models.SandboxCredential, error) {
+	store, err := secretstore.Detect(path)
 	if err != nil {
-		return nil, errors.New("not logged in: run `bear login`")
+		return nil, err
 	}
 
-	var cred models.PsAzureCredential
-	if err := json.Unmarshal(data, &cred); err != nil {
+	data, err := store.Load(profile, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored storedSandboxCredential
+	if err := json.Unmarshal(data, &stored); err != nil {
 		return nil, err
 	}
 
-	return &cred, nil
+	if stored.Provider == "" {
+		stored.Provider = legacyProvider(data)
+		stored.Credential = data
+	}
+
+	cred, err := models.NewSandboxCredential(stored.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(stored.Credential, cred); err != nil {
+		return nil, err
+	}
+
+	return cred, nil
+}
+
+// Works out the provider of a file written before the provider was recorded. Those held either an
+// AWS or an Azure credential, told apart by the AWS access key.
+func legacyProvider(data []byte) string {
+	var aws models.AWSCredential
+	if err := json.Unmarshal(data, &aws); err == nil && aws.AccessKeyId != "" {
+		return models.ProviderAWS
+	}
+
+	return models.ProviderAzure
+}
+
+func loadAzureSandboxCredential(cred models.SandboxCredential) (*models.PsAzureCredential, error) {
+	azureCred, ok := cred.(*models.PsAzureCredential)
+	if !ok {
+		return nil, fmt.Errorf("stored credential is for %s, not %s", cred.Provider(), models.ProviderAzure)
+	}
+
+	return azureCred, nil
 }
 
-func PurgeSandboxCredential() error {
-	path, err := loadSandboxPath()
+func PurgeSandboxCredential(profile string) error {
+	path, err := loadSandboxPath(profile)
 	if err != nil {
 		return err
 	}
 
-	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+	metadata, _, err := LoadProfileMetadata(profile)
+	if err != nil {
 		return err
 	}
+	for _, awsProfile := range metadata.AWSProfiles {
+		if err := awscli.RemoveProfile(awsProfile); err != nil {
+			return fmt.Errorf("failed to remove AWS profile %s: %w", awsProfile, err)
+		}
+	}
 
-	return nil
+	for _, dir := range metadata.AzureConfigDirs {
+		if err := removeAzureConfigDir(profile, dir); err != nil {
+			return fmt.Errorf("failed to remove Azure CLI config %s: %w", dir, err)
+		}
+	}
+
+	if err := removeProfileMetadata(profile); err != nil {
+		return err
+	}
+
+	// A browser profile still open in Chrome is wiped by PurgeStaleBrowserProfiles once it is closed
+	browserDir, err := BrowserProfileDir(profile)
+	if err != nil {
+		return err
+	}
+	if !browserProfileInUse(browserDir) {
+		if err := os.RemoveAll(browserDir); err != nil {
+			return err
+		}
+	}
+
+	if err := shredKeyFile(profile); err != nil {
+		return err
+	}
+
+	store, err := secretstore.Detect(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return store.Purge(profile, path)
+}
+
+func RequireSandbox(profile string) (models.SandboxCredential, error) {
+	return LoadSandboxCredential(profile)
 }
 
-func RequireSandbox() (*models.PsAzureCredential, error) {
-	return LoadSandboxCredential()
+type CreateCredentialOptions struct {
+	UseClipboard bool
+	HTMLPath     string
+	Profile      string
+	TTL          time.Duration
+	Store        secretstore.Store
+	// Save the credential even when required fields are missing or invalid
+	AllowPartial bool
+	// Receives the field validation report, if set
+	Report io.Writer
 }
 
-func CreatePsAWSCredential(useClipboard bool, filePath string) models.PsAwsCredential {
-	extractorCred := RunExtractor(useClipboard, filePath, models.AWS)
+// Extracts the sandbox page and checks the fields the provider needs before anything is saved.
+func extractAndValidate(opts CreateCredentialOptions, provider models.CloudProvider) (map[string]string, ValidationReport, error) {
+	extractorCred, err := RunExtractor(opts.UseClipboard, opts.HTMLPath, provider)
+	if err != nil {
+		return nil, ValidationReport{}, err
+	}
+
+	report := ValidateExtraction(provider, extractorCred)
+	if opts.Report != nil {
+		report.Print(opts.Report)
+	}
 
+	if failed := report.Failed(); len(failed) > 0 && !opts.AllowPartial {
+		return extractorCred, report, &MissingFieldError{Provider: string(provider), Fields: failed}
+	}
+
+	return extractorCred, report, nil
+}
+
+func CreatePsAWSCredential(opts CreateCredentialOptions) (models.PsAwsCredential, error) {
 	var psAWSCred models.PsAwsCredential
+
+	extractorCred, _, err := extractAndValidate(opts, models.AWS)
+	if err != nil {
+		return psAWSCred, err
+	}
+
 	psAWSCred.SandboxURL = extractorCred["SANDBOX_URL"]
 	psAWSCred.User = extractorCred["USERNAME"]
 	psAWSCred.Password = extractorCred["PASSWORD"]
 	psAWSCred.AccessKeyId = extractorCred["ACCESS_KEY_ID"]
 	psAWSCred.SecretAccessKey = extractorCred["SECRET_ACCESS_KEY"]
 	psAWSCred.Region = extractorCred["REGION"]
+	psAWSCred.Expiration = sandboxExpiration(opts.TTL, extractorCred)
 
-	if err := SaveSandboxCredential(&psAWSCred); err != nil {
-		log.Fatalf("failed to save sandbox credential: %v", err)
+	if err := SaveSandboxCredential(&psAWSCred, opts.Profile, opts.Store); err != nil {
+		return psAWSCred, fmt.Errorf("failed to save sandbox credential: %w", err)
 	}
 
-	return psAWSCred
+	return psAWSCred, nil
 }
 
-func CreatePsAzureCredential(useClipboard bool, filePath string) models.PsAzureCredential {
-	extractorCred := RunExtractor(useClipboard, filePath, models.Azure)
-
+func CreatePsAzureCredential(opts CreateCredentialOptions) (models.PsAzureCredential, error) {
 	var psARMCred models.PsAzureCredential
+
+	extractorCred, report, err := extractAndValidate(opts, models.Azure)
+	if err != nil {
+		return psARMCred, err
+	}
+
 	psARMCred.SandboxURL = extractorCred["SANDBOX_URL"]
 	psARMCred.SubscriptionID = extractorCred["SUBSCRIPTION_ID"]
 	psARMCred.User = extractorCred["USER"]
@@ -209,15 +320,228 @@ func CreatePsAzureCredential(useClipboard bool, filePath string) models.PsAzureC
 	psARMCred.ResourceGroup = extractorCred["RESOURCE_GROUP"]
 	psARMCred.TenantName = extractorCred["TENANT_NAME"]
 	psARMCred.ResourceProviderRegistrations = "none"
+	psARMCred.Expiration = sandboxExpiration(opts.TTL, extractorCred)
+
+	// A partial credential may lack what the token exchange needs; save it without a tenant ID then
+	if report.Has("CLIENT_ID") && report.Has("CLIENT_SECRET") && report.Has("TENANT_NAME") {
+		armToken, err := armapi.GetARMToken(psARMCred.ClientID, psARMCred.ClientSecret, psARMCred.TenantName)
+		if err != nil {
+			return psARMCred, err
+		}
+		psARMCred.TenantID, err = armapi.GetTenantId(armToken)
+		if err != nil {
+			return psARMCred, err
+		}
+	}
+
+	if err := SaveSandboxCredential(&psARMCred, opts.Profile, opts.Store); err != nil {
+		return psARMCred, fmt.Errorf("failed to save sandbox credential: %w", err)
+	}
+
+	return psARMCred, nil
+}
+
+func CreatePsGCPCredential(opts CreateCredentialOptions) (models.PsGcpCredential, error) {
+	var psGCPCred models.PsGcpCredential
+
+	extractorCred, _, err := extractAndValidate(opts, models.GCP)
+	if err != nil {
+		return psGCPCred, err
+	}
+
+	psGCPCred.SandboxURL = extractorCred["SANDBOX_URL"]
+	psGCPCred.User = extractorCred["USERNAME"]
+	psGCPCred.Password = extractorCred["PASSWORD"]
+	psGCPCred.ProjectID = extractorCred["PROJECT_ID"]
+	psGCPCred.ServiceAccountKey = extractorCred["SERVICE_ACCOUNT_KEY"]
+	psGCPCred.Expiration = sandboxExpiration(opts.TTL, extractorCred)
+
+	// GOOGLE_APPLICATION_CREDENTIALS only accepts a path. A plaintext store keeps the key in a file of its own
+	// as well; an encrypted store must not leave it on disk, so exec only writes it for the child's lifetime.
+	var keyFile string
+	if psGCPCred.ServiceAccountKey != "" && opts.Store.Name() == secretstore.File {
+		if keyFile, err = gcpKeyFilePath(opts.Profile); err != nil {
+			return psGCPCred, err
+		}
+		psGCPCred.KeyFile = keyFile
+	}
+
+	if err := SaveSandboxCredential(&psGCPCred, opts.Profile, opts.Store); err != nil {
+		return psGCPCred, fmt.Errorf("failed to save sandbox credential: %w", err)
+	}
 
-	armToken := armapi.GetARMToken(psARMCred.ClientID, psARMCred.ClientSecret, psARMCred.TenantName)
-	psARMCred.TenantID = armapi.GetTenantId(armToken)
+	// Only written once the credential is saved, so a failed save never leaves a stray key behind
+	if keyFile != "" {
+		if err := writeKeyFile(keyFile, psGCPCred.ServiceAccountKey); err != nil {
+			return psGCPCred, err
+		}
+	} else if err := shredKeyFile(opts.Profile); err != nil {
+		return psGCPCred, err
+	}
+
+	return psGCPCred, nil
+}
+
+func writeKeyFile(path, key string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(key), 0600); err != nil {
+		return fmt.Errorf("failed to write service account key: %w", err)
+	}
+	return nil
+}
+
+// Removes the key file a plaintext GCP profile left behind, if any.
+func shredKeyFile(profile string) error {
+	keyFile, err := gcpKeyFilePath(profile)
+	if err != nil {
+		return err
+	}
+	return secretstore.Shred(keyFile)
+}
 
-	if err := SaveSandboxCredential(&psARMCred); err != nil {
-		log.Fatalf("failed to save sandbox credential: %v", err)
+func loadGCPSandboxCredential(cred models.SandboxCredential) (*models.PsGcpCredential, error) {
+	gcpCred, ok := cred.(*models.PsGcpCredential)
+	if !ok {
+		return nil, fmt.Errorf("stored credential is for %s, not %s", cred.Provider(), models.ProviderGCP)
 	}
 
-	return psARMCred
+	return gcpCred, nil
+}
+
+func loadAWSSandboxCredential(cred models.SandboxCredential) (*models.PsAwsCredential, error) {
+	awsCred, ok := cred.(*models.PsAwsCredential)
+	if !ok {
+		return nil, fmt.Errorf("stored credential is for %s, not %s", cred.Provider(), models.ProviderAWS)
+	}
+
+	return awsCred, nil
+}
+
+// Loads the AWS credential of a profile for credential_process, refusing sandboxes that have expired.
+func AWSCredentialProcess(profile string) (awscli.CredentialProcessOutput, error) {
+	stored, err := LoadSandboxCredential(profile)
+	if err != nil {
+		return awscli.CredentialProcessOutput{}, err
+	}
+
+	cred, err := loadAWSSandboxCredential(stored)
+	if err != nil {
+		return awscli.CredentialProcessOutput{}, err
+	}
+
+	if cred.IsExpired() {
+		return awscli.CredentialProcessOutput{}, &SandboxExpiredError{Profile: profile, ExpiredAt: cred.ExpiresAt()}
+	}
+
+	return awscli.NewCredentialProcessOutput(cred.AWSCredential, cred.ExpiresAt()), nil
+}
+
+// The credential_process command that serves the profile's credential through this bear binary.
+func credentialProcessCommand(profile string) string {
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "bear"
+	}
+	if strings.ContainsAny(executable, " \t") {
+		executable = strconv.Quote(executable)
+	}
+
+	return fmt.Sprintf("%s ps %s --profile %s", executable, models.PsAWSCredentialProcess, profile)
+}
+
+// Writes the credential as a named profile of the AWS CLI/SDK shared files and remembers it for purge.
+// With useCredentialProcess the profile calls back into bear instead of holding the keys.
+func WriteAWSSharedProfile(profile string, cred models.SandboxCredential, awsProfile string, useCredentialProcess bool) error {
+	awsCred, err := loadAWSSandboxCredential(cred)
+	if err != nil {
+		return fmt.Errorf("cannot write an AWS profile: %w", err)
+	}
+
+	if useCredentialProcess {
+		err = awscli.WriteCredentialProcessProfile(awsProfile, credentialProcessCommand(profile), awsCred.Region)
+	} else {
+		err = awscli.WriteProfile(awsProfile, awsCred.AWSCredential)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write AWS profile %s: %w", awsProfile, err)
+	}
+
+	metadata, _, err := LoadProfileMetadata(profile)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(metadata.AWSProfiles, awsProfile) {
+		metadata.AWSProfiles = append(metadata.AWSProfiles, awsProfile)
+	}
+	metadata.Provider = cred.Provider()
+	metadata.Expiration = cred.ExpiresAt()
+
+	return writeProfileMetadata(profile, metadata)
+}
+
+// Logs an isolated Azure CLI config directory into the sandbox service principal and remembers it for purge.
+// An empty dir selects the profile's own directory; the directory used is returned.
+func WriteAzureCLIConfig(profile string, cred models.SandboxCredential, dir string) (string, error) {
+	azureCred, err := loadAzureSandboxCredential(cred)
+	if err != nil {
+		return "", fmt.Errorf("cannot write an Azure CLI config: %w", err)
+	}
+
+	if dir == "" {
+		dir, err = AzureConfigDirPath(profile)
+		if err != nil {
+			return "", err
+		}
+	}
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	// The tenant ID is unknown when the token exchange was skipped, but az accepts the tenant domain too
+	tenant := azureCred.TenantID
+	if tenant == "" {
+		tenant = azureCred.TenantName
+	}
+
+	if err := azcli.WriteConfigDir(dir, azureCred.ARMCredential, tenant); err != nil {
+		var unmanaged *azcli.UnmanagedConfigDirError
+		if errors.As(err, &unmanaged) {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to write Azure CLI config %s: %w", dir, err)
+	}
+
+	metadata, _, err := LoadProfileMetadata(profile)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(metadata.AzureConfigDirs, dir) {
+		metadata.AzureConfigDirs = append(metadata.AzureConfigDirs, dir)
+	}
+	metadata.Provider = cred.Provider()
+	metadata.Expiration = cred.ExpiresAt()
+
+	return dir, writeProfileMetadata(profile, metadata)
+}
+
+// Removes the Azure CLI config bear wrote for the profile.
+// The profile's own directory goes entirely; a directory chosen by the user keeps whatever else az put there.
+func removeAzureConfigDir(profile string, dir string) error {
+	ownDir, err := AzureConfigDirPath(profile)
+	if err != nil {
+		return err
+	}
+	if dir != ownDir {
+		return azcli.RemoveConfigDir(dir)
+	}
+
+	if err := secretstore.Shred(filepath.Join(dir, azcli.ServicePrincipalEntriesFile)); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
 }
 
 func DetectOldResourceGroup(content string) (string, bool) {
@@ -232,26 +556,21 @@ func DetectOldResourceGroup(content string) (string, bool) {
 
 func ReplaceResourceGroupFromSandbox(
 	content string,
-	sandboxPath string,
+	resourceGroup string,
 ) (string, bool, error) {
 
-	cred, err := LoadSandboxCredential()
-	if err != nil {
-		return "", false, err
-	}
-
 	oldRG, found := DetectOldResourceGroup(content)
 	if !found {
 		return content, false, nil
 	}
 
-	updated := strings.ReplaceAll(content, oldRG, cred.ResourceGroup)
+	updated := strings.ReplaceAll(content, oldRG, resourceGroup)
 	return updated, true, nil
 }
 
 func ReplaceResourceGroupInFile(
 	filePath string,
-	sandboxPath string,
+	resourceGroup string,
 ) error {
 
 	data, err := os.ReadFile(filePath)
@@ -261,7 +580,7 @@ func ReplaceResourceGroupInFile(
 
 	updated, changed, err := ReplaceResourceGroupFromSandbox(
 		string(data),
-		sandboxPath,
+		resourceGroup,
 	)
 	if err != nil {
 		return err
@@ -277,6 +596,7 @@ func ReplaceResourceGroupInFile(
 func ReplaceResourceGroupInPath(
 	path string,
 	sandboxPath string,
+	profile string,
 ) error {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -284,11 +604,24 @@ func ReplaceResourceGroupInPath(
 	}
 
 	if sandboxPath == "" {
-		sandboxPath, _ = loadSandboxPath()
+		sandboxPath, err = loadSandboxPath(profile)
+		if err != nil {
+			return err
+		}
 	}
 	fmt.Println(path)
 	fmt.Println(sandboxPath)
 
+	stored, err := loadSandboxCredentialFile(sandboxPath, profile)
+	if err != nil {
+		return err
+	}
+
+	cred, err := loadAzureSandboxCredential(stored)
+	if err != nil {
+		return err
+	}
+
 	if info.IsDir() {
 		return filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
 			if err != nil {
@@ -302,32 +635,14 @@ func ReplaceResourceGroupInPath(
 			// Optional: filter file types
 			switch filepath.Ext(p) {
 			case ".tf", ".tfvars", ".txt", ".sh":
-				return ReplaceResourceGroupInFile(p, sandboxPath)
+				return ReplaceResourceGroupInFile(p, cred.ResourceGroup)
 			default:
 				return nil
 			}
 		})
 	}
 
-	return ReplaceResourceGroupInFile(path, sandboxPath)
-}
-
-func LoginAzurePortalFromSandbox() error {
-	cred, err := LoadSandboxCredential()
-	if err != nil {
-		return err
-	}
-
-	if cred.User == "" || cred.Password == "" {
-		return fmt.Errorf("sandbox credential missing username or password")
-	}
-
-	if cred.SandboxURL == "" {
-		return fmt.Errorf("sandbox credential missing portal URL")
-	}
-
-	browser.LoginInBrowser(cred.User, cred.Password, browser.AzurePortal, string(browser.AzurePortal))
-	return nil
+	return ReplaceResourceGroupInFile(path, cred.ResourceGroup)
 }
 
 func RemoveTerraformStateFiles(root string) error {
@@ -0,0 +1,220 @@
+package ps
+
+import (
+	"bear_cli/models"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+const rulesVersion = 1
+
+//go:embed rules.yaml
+var defaultRules []byte
+
+type ExtractionRules struct {
+	Version   int                      `yaml:"version"`
+	Providers map[string]ProviderRules `yaml:"providers"`
+}
+
+type ProviderRules struct {
+	Fields []FieldRule `yaml:"fields"`
+}
+
+// Describes how one credential field is located on the sandbox page.
+type FieldRule struct {
+	Key        string `yaml:"key"`
+	Selector   string `yaml:"selector"`
+	TextMatch  string `yaml:"textMatch"`
+	ParentFind string `yaml:"parentFind"`
+	Source     string `yaml:"source"`
+	From       string `yaml:"from"`
+	Regex      string `yaml:"regex"`
+
+	textMatch *regexp.Regexp
+	regex     *regexp.Regexp
+}
+
+// Returns the rules file shipped with bear, as a starting point for an override.
+func DefaultExtractionRules() []byte {
+	return defaultRules
+}
+
+func rulesPath() (string, error) {
+	dir, err := psConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "rules.yaml"), nil
+}
+
+// Returns the built-in rules, with providers from ~/.config/bear/ps/rules.yaml replacing the built-in ones.
+func LoadExtractionRules() (ExtractionRules, error) {
+	rules, err := parseExtractionRules(defaultRules, "built-in rules")
+	if err != nil {
+		return rules, err
+	}
+
+	path, err := rulesPath()
+	if err != nil {
+		return rules, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return rules, nil
+	}
+	if err != nil {
+		return rules, err
+	}
+
+	overrides, err := parseExtractionRules(data, path)
+	if err != nil {
+		return rules, err
+	}
+
+	for provider, providerRules := range overrides.Providers {
+		rules.Providers[provider] = providerRules
+	}
+
+	return rules, nil
+}
+
+func parseExtractionRules(data []byte, origin string) (ExtractionRules, error) {
+	var rules ExtractionRules
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("invalid extraction rules in %s: %w", origin, err)
+	}
+
+	if rules.Version != rulesVersion {
+		return rules, fmt.Errorf("unsupported extraction rules version %d in %s (expected %d)", rules.Version, origin, rulesVersion)
+	}
+
+	if rules.Providers == nil {
+		rules.Providers = map[string]ProviderRules{}
+	}
+
+	for provider, providerRules := range rules.Providers {
+		for i := range providerRules.Fields {
+			if err := providerRules.Fields[i].compile(); err != nil {
+				return rules, fmt.Errorf("invalid %s extraction rule in %s: %w", provider, origin, err)
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+func (r *FieldRule) compile() error {
+	if r.Key == "" {
+		return errors.New("rule without a key")
+	}
+	if (r.Selector == "") == (r.From == "") {
+		return fmt.Errorf("rule %s must set exactly one of selector or from", r.Key)
+	}
+
+	for _, sel := range []string{r.Selector, r.ParentFind} {
+		if sel == "" {
+			continue
+		}
+		if _, err := cascadia.Compile(sel); err != nil {
+			return fmt.Errorf("rule %s: invalid selector %q: %w", r.Key, sel, err)
+		}
+	}
+
+	if r.Source != "" && r.Source != "text" && !strings.HasPrefix(r.Source, "attr:") {
+		return fmt.Errorf("rule %s: source must be \"text\" or \"attr:<name>\"", r.Key)
+	}
+
+	var err error
+	if r.TextMatch != "" {
+		if r.textMatch, err = regexp.Compile(r.TextMatch); err != nil {
+			return fmt.Errorf("rule %s: invalid textMatch: %w", r.Key, err)
+		}
+	}
+	if r.Regex != "" {
+		if r.regex, err = regexp.Compile(r.Regex); err != nil {
+			return fmt.Errorf("rule %s: invalid regex: %w", r.Key, err)
+		}
+	}
+
+	return nil
+}
+
+func (r ProviderRules) apply(doc *goquery.Document) map[string]string {
+	creds := make(map[string]string)
+
+	for _, rule := range r.Fields {
+		var value string
+		if rule.From != "" {
+			value = creds[rule.From]
+		} else {
+			value = rule.find(doc)
+		}
+
+		value = rule.postProcess(value)
+		if value != "" {
+			creds[rule.Key] = value
+		}
+	}
+
+	return creds
+}
+
+// Returns the value of the first matching element that yields something non-empty.
+func (r FieldRule) find(doc *goquery.Document) string {
+	var value string
+
+	doc.Find(r.Selector).EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if r.textMatch != nil && !r.textMatch.MatchString(strings.TrimSpace(s.Text())) {
+			return true
+		}
+
+		if r.ParentFind != "" {
+			s = s.Parent().Find(r.ParentFind).First()
+		}
+
+		if attr, ok := strings.CutPrefix(r.Source, "attr:"); ok {
+			value, _ = s.Attr(attr)
+		} else {
+			value = strings.TrimSpace(s.Text())
+		}
+
+		return value == ""
+	})
+
+	return value
+}
+
+func (r FieldRule) postProcess(value string) string {
+	if r.regex == nil || value == "" {
+		return value
+	}
+
+	m := r.regex.FindStringSubmatch(value)
+	switch {
+	case m == nil:
+		return ""
+	case len(m) > 1:
+		return m[1]
+	default:
+		return m[0]
+	}
+}
+
+func rulesFor(rules ExtractionRules, cloudProvider models.CloudProvider) (ProviderRules, error) {
+	providerRules, ok := rules.Providers[strings.ToLower(string(cloudProvider))]
+	if !ok {
+		return providerRules, fmt.Errorf("no extraction rules for provider %s", cloudProvider)
+	}
+	return providerRules, nil
+}
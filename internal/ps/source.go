@@ -0,0 +1,178 @@
+package ps
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+)
+
+// Reads the sandbox page from --html-path ("-" for stdin) or the clipboard.
+// The content may be plain HTML, a saved MHTML archive or a HAR capture.
+func readHTMLSource(useClipboard bool, htmlPath string) (string, error) {
+	var raw []byte
+	var source string
+
+	switch {
+	case htmlPath == "-":
+		source = "stdin"
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", &HTMLSourceError{Source: source, Err: err}
+		}
+		raw = data
+	case htmlPath != "":
+		source = htmlPath
+		data, err := os.ReadFile(htmlPath)
+		if err != nil {
+			return "", &HTMLSourceError{Source: source, Err: err}
+		}
+		raw = data
+	case useClipboard:
+		source = "clipboard"
+		text, err := clipboard.ReadAll()
+		if err != nil {
+			return "", &HTMLSourceError{Source: source, Err: err}
+		}
+		raw = []byte(text)
+	default:
+		return "", ErrNoHTMLSource
+	}
+
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return "", &HTMLSourceError{Source: source, Err: errors.New("content is empty")}
+	}
+
+	html, err := extractHTML(raw)
+	if err != nil {
+		return "", &HTMLSourceError{Source: source, Err: err}
+	}
+
+	return html, nil
+}
+
+func extractHTML(raw []byte) (string, error) {
+	trimmed := bytes.TrimSpace(raw)
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		return htmlFromHAR(trimmed)
+	case isMHTML(trimmed):
+		return htmlFromMHTML(trimmed)
+	default:
+		return string(raw), nil
+	}
+}
+
+func isMHTML(data []byte) bool {
+	head := data
+	if len(head) > 2048 {
+		head = head[:2048]
+	}
+	header := strings.ToLower(string(head))
+	return strings.Contains(header, "mime-version:") && strings.Contains(header, "multipart/related")
+}
+
+// Returns the main HTML document of a saved MHTML page (the first text/html part).
+func htmlFromMHTML(data []byte) (string, error) {
+	msg, err := mail.ReadMessage(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse MHTML: %w", err)
+	}
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse MHTML: %w", err)
+	}
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse MHTML: %w", err)
+		}
+
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if mediaType != "text/html" {
+			continue
+		}
+
+		// Quoted-printable is decoded by the multipart reader, base64 is not
+		var body io.Reader = part
+		if strings.EqualFold(part.Header.Get("Content-Transfer-Encoding"), "base64") {
+			body = base64.NewDecoder(base64.StdEncoding, part)
+		}
+
+		content, err := io.ReadAll(body)
+		if err != nil {
+			return "", fmt.Errorf("failed to read MHTML part: %w", err)
+		}
+		return string(content), nil
+	}
+
+	return "", errors.New("no text/html part found in MHTML")
+}
+
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Response struct {
+				Content struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+					Encoding string `json:"encoding"`
+				} `json:"content"`
+			} `json:"response"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// Returns the HTML response of a HAR capture, preferring the one that holds the sandbox details.
+func htmlFromHAR(data []byte) (string, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return "", fmt.Errorf("failed to parse HAR: %w", err)
+	}
+
+	var pages []string
+	for _, entry := range har.Log.Entries {
+		content := entry.Response.Content
+		if !strings.HasPrefix(content.MimeType, "text/html") || content.Text == "" {
+			continue
+		}
+
+		text := content.Text
+		if content.Encoding == "base64" {
+			decoded, err := base64.StdEncoding.DecodeString(text)
+			if err != nil {
+				return "", fmt.Errorf("failed to decode HAR entry: %w", err)
+			}
+			text = string(decoded)
+		}
+		pages = append(pages, text)
+	}
+
+	if len(pages) == 0 {
+		return "", errors.New("no HTML response found in HAR")
+	}
+
+	for _, page := range pages {
+		if strings.Contains(page, "Sandbox URL") {
+			return page, nil
+		}
+	}
+	return pages[0], nil
+}
@@ -0,0 +1,181 @@
+package ps
+
+import (
+	"bear_cli/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+)
+
+type FieldStatus string
+
+const (
+	FieldOK      FieldStatus = "ok"
+	FieldMissing FieldStatus = "missing"
+	FieldInvalid FieldStatus = "invalid"
+)
+
+type FieldResult struct {
+	Key      string
+	Label    string
+	Required bool
+	Status   FieldStatus
+	Detail   string
+}
+
+// Outcome of checking the fields extracted from a sandbox page against what the provider needs.
+type ValidationReport struct {
+	Provider models.CloudProvider
+	Fields   []FieldResult
+}
+
+type fieldRule struct {
+	key      string
+	label    string
+	required bool
+	// Returns a description of what is wrong with a non-empty value, or "" when it is valid.
+	check func(value string) string
+}
+
+var (
+	awsAccessKeyPattern = regexp.MustCompile(`^(AKIA|ASIA)[A-Z0-9]{16}$`)
+	guidPattern         = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	gcpProjectIDPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+)
+
+var fieldRules = map[models.CloudProvider][]fieldRule{
+	models.AWS: {
+		{key: "USERNAME", label: "Username", required: true},
+		{key: "PASSWORD", label: "Password", required: true},
+		{key: "ACCESS_KEY_ID", label: "Access Key Id", required: true, check: checkAWSAccessKey},
+		{key: "SECRET_ACCESS_KEY", label: "Secret Access Key", required: true},
+		{key: "SANDBOX_URL", label: "Sandbox URL", required: true, check: checkURL},
+		{key: "REGION", label: "Region (from Sandbox URL)"},
+	},
+	models.Azure: {
+		{key: "USER", label: "Username", required: true},
+		{key: "PASSWORD", label: "Password", required: true},
+		{key: "CLIENT_ID", label: "Application Client ID", required: true, check: checkGUID},
+		{key: "CLIENT_SECRET", label: "Secret", required: true},
+		{key: "SANDBOX_URL", label: "Sandbox URL", required: true, check: checkURL},
+		{key: "TENANT_NAME", label: "Tenant (from Sandbox URL)", required: true},
+		{key: "SUBSCRIPTION_ID", label: "Subscription ID (from Sandbox URL)", required: true, check: checkGUID},
+		{key: "RESOURCE_GROUP", label: "Resource group (from Sandbox URL)"},
+	},
+	models.GCP: {
+		{key: "USERNAME", label: "Username", required: true},
+		{key: "PASSWORD", label: "Password", required: true},
+		{key: "SERVICE_ACCOUNT_KEY", label: "Service Account Credentials", required: true, check: checkServiceAccountKey},
+		{key: "PROJECT_ID", label: "Project ID", required: true, check: checkGCPProjectID},
+		{key: "SANDBOX_URL", label: "Sandbox URL", required: true, check: checkURL},
+	},
+}
+
+func checkAWSAccessKey(value string) string {
+	if !awsAccessKeyPattern.MatchString(value) {
+		return "not an AWS access key ID (expected AKIA… or ASIA… followed by 16 characters)"
+	}
+	return ""
+}
+
+func checkGUID(value string) string {
+	if !guidPattern.MatchString(value) {
+		return "not a GUID"
+	}
+	return ""
+}
+
+func checkServiceAccountKey(value string) string {
+	var key struct {
+		Type        string `json:"type"`
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+	}
+	if err := json.Unmarshal([]byte(value), &key); err != nil {
+		return "not valid JSON"
+	}
+	if key.Type != "service_account" || key.ClientEmail == "" || key.PrivateKey == "" {
+		return "not a service account key (expected type, client_email and private_key)"
+	}
+	return ""
+}
+
+func checkGCPProjectID(value string) string {
+	if !gcpProjectIDPattern.MatchString(value) {
+		return "not a GCP project ID"
+	}
+	return ""
+}
+
+func checkURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "not an http(s) URL"
+	}
+	return ""
+}
+
+func ValidateExtraction(provider models.CloudProvider, extracted map[string]string) ValidationReport {
+	report := ValidationReport{Provider: provider}
+
+	for _, rule := range fieldRules[provider] {
+		result := FieldResult{Key: rule.key, Label: rule.label, Required: rule.required, Status: FieldOK}
+
+		value := extracted[rule.key]
+		if value == "" {
+			result.Status = FieldMissing
+		} else if rule.check != nil {
+			if problem := rule.check(value); problem != "" {
+				result.Status = FieldInvalid
+				result.Detail = problem
+			}
+		}
+
+		report.Fields = append(report.Fields, result)
+	}
+
+	return report
+}
+
+// Labels of required fields that are missing or invalid.
+func (r ValidationReport) Failed() []string {
+	failed := []string{}
+	for _, f := range r.Fields {
+		if f.Required && f.Status != FieldOK {
+			failed = append(failed, f.Label)
+		}
+	}
+	return failed
+}
+
+func (r ValidationReport) Has(key string) bool {
+	for _, f := range r.Fields {
+		if f.Key == key {
+			return f.Status == FieldOK
+		}
+	}
+	return false
+}
+
+// Writes a found-vs-missing summary. Values are never printed since most of them are secrets.
+func (r ValidationReport) Print(w io.Writer) {
+	width := 0
+	for _, f := range r.Fields {
+		width = max(width, len(f.Label))
+	}
+
+	fmt.Fprintf(w, "%s sandbox fields:\n", r.Provider)
+	for _, f := range r.Fields {
+		status := string(f.Status)
+		if f.Status != FieldOK && !f.Required {
+			status += " (optional)"
+		}
+		line := fmt.Sprintf("  %-*s  %s", width, f.Label, status)
+		if f.Detail != "" {
+			line += ": " + f.Detail
+		}
+		fmt.Fprintln(w, line)
+	}
+}
@@ -0,0 +1,111 @@
+package secretstore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"golang.org/x/term"
+)
+
+const ageArmorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+
+const PassphraseEnv = "BEAR_PS_PASSPHRASE"
+
+// Encrypts the credential with an age scrypt recipient derived from a passphrase.
+type ageStore struct{}
+
+func (s *ageStore) Name() string {
+	return Age
+}
+
+func (s *ageStore) Save(profile, path string, data []byte) error {
+	passphrase, err := readPassphrase(profile)
+	if err != nil {
+		return err
+	}
+
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	armorWriter := armor.NewWriter(&buf)
+	w, err := age.Encrypt(armorWriter, recipient)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := armorWriter.Close(); err != nil {
+		return err
+	}
+
+	return writeFile(path, buf.Bytes())
+}
+
+func (s *ageStore) Load(profile, path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	passphrase, err := readPassphrase(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := age.Decrypt(armor.NewReader(f), identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credential for profile %q: %w", profile, err)
+	}
+
+	return io.ReadAll(r)
+}
+
+func (s *ageStore) Purge(profile, path string) error {
+	return Shred(path)
+}
+
+// Takes the passphrase from the environment, falling back to an interactive prompt on stderr
+// so stdout stays clean for `eval $(bear ps get-cred)`.
+func readPassphrase(profile string) (string, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no passphrase for profile %q: set %s or run interactively", profile, PassphraseEnv)
+	}
+
+	fmt.Fprintf(os.Stderr, "Passphrase for profile %s: ", profile)
+	input, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	passphrase := strings.TrimSpace(string(input))
+	if passphrase == "" {
+		return "", errors.New("passphrase must not be empty")
+	}
+
+	return passphrase, nil
+}
@@ -0,0 +1,22 @@
+package secretstore
+
+import "os"
+
+// Stores the credential as plaintext JSON, as bear always did.
+type fileStore struct{}
+
+func (s *fileStore) Name() string {
+	return File
+}
+
+func (s *fileStore) Save(profile, path string, data []byte) error {
+	return writeFile(path, data)
+}
+
+func (s *fileStore) Load(profile, path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func (s *fileStore) Purge(profile, path string) error {
+	return Shred(path)
+}
@@ -0,0 +1,250 @@
+package secretstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	secretServiceName     = "org.freedesktop.secrets"
+	secretServicePath     = "/org/freedesktop/secrets"
+	secretServiceIface    = "org.freedesktop.Secret.Service"
+	secretCollectionIface = "org.freedesktop.Secret.Collection"
+	secretItemIface       = "org.freedesktop.Secret.Item"
+	secretPromptIface     = "org.freedesktop.Secret.Prompt"
+	secretDefaultAlias    = "/org/freedesktop/secrets/aliases/default"
+
+	keyringServiceAttr = "bear-cli"
+	keyringPromptWait  = 2 * time.Minute
+)
+
+// Wire format of org.freedesktop.Secret.Secret, (oayays).
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// Keeps the credential in the Secret Service (GNOME Keyring, KWallet, KeePassXC, ...) over the
+// D-Bus session bus. DBUS_SESSION_BUS_ADDRESS can point it at a local stand-in service.
+type keyringStore struct{}
+
+type keyringSession struct {
+	conn    *dbus.Conn
+	service dbus.BusObject
+	path    dbus.ObjectPath
+}
+
+func (s *keyringStore) Name() string {
+	return Keyring
+}
+
+func (s *keyringStore) Save(profile, path string, data []byte) error {
+	session, err := openKeyringSession()
+	if err != nil {
+		return err
+	}
+	defer session.close()
+
+	collection, err := session.defaultCollection()
+	if err != nil {
+		return err
+	}
+	if err := session.unlock([]dbus.ObjectPath{collection}); err != nil {
+		return err
+	}
+
+	props := map[string]dbus.Variant{
+		secretItemIface + ".Label":      dbus.MakeVariant(fmt.Sprintf("bear ps credential (%s)", profile)),
+		secretItemIface + ".Attributes": dbus.MakeVariant(keyringAttributes(profile)),
+	}
+	value := secret{
+		Session:     session.path,
+		Parameters:  []byte{},
+		Value:       data,
+		ContentType: "application/json",
+	}
+
+	var item, prompt dbus.ObjectPath
+	call := session.conn.Object(secretServiceName, collection).Call(secretCollectionIface+".CreateItem", 0, props, value, true)
+	if err := call.Store(&item, &prompt); err != nil {
+		return fmt.Errorf("failed to store credential in keyring: %w", err)
+	}
+	if err := session.prompt(prompt); err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(marker{Store: Keyring})
+	if err != nil {
+		return err
+	}
+
+	return writeFile(path, content)
+}
+
+func (s *keyringStore) Load(profile, path string) ([]byte, error) {
+	session, err := openKeyringSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.close()
+
+	items, err := session.search(profile)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no keyring entry for profile %q", profile)
+	}
+
+	var value secret
+	call := session.conn.Object(secretServiceName, items[0]).Call(secretItemIface+".GetSecret", 0, session.path)
+	if err := call.Store(&value); err != nil {
+		return nil, fmt.Errorf("failed to read credential from keyring: %w", err)
+	}
+
+	return value.Value, nil
+}
+
+func (s *keyringStore) Purge(profile, path string) error {
+	session, err := openKeyringSession()
+	if err != nil {
+		return err
+	}
+	defer session.close()
+
+	items, err := session.search(profile)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var prompt dbus.ObjectPath
+		call := session.conn.Object(secretServiceName, item).Call(secretItemIface+".Delete", 0)
+		if err := call.Store(&prompt); err != nil {
+			return fmt.Errorf("failed to delete keyring entry: %w", err)
+		}
+		if err := session.prompt(prompt); err != nil {
+			return err
+		}
+	}
+
+	return Shred(path)
+}
+
+func keyringAttributes(profile string) map[string]string {
+	return map[string]string{
+		"service": keyringServiceAttr,
+		"profile": profile,
+	}
+}
+
+func openKeyringSession() (*keyringSession, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the D-Bus session bus: %w", err)
+	}
+
+	service := conn.Object(secretServiceName, secretServicePath)
+
+	var output dbus.Variant
+	var path dbus.ObjectPath
+	if err := service.Call(secretServiceIface+".OpenSession", 0, "plain", dbus.MakeVariant("")).Store(&output, &path); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open Secret Service session: %w", err)
+	}
+
+	return &keyringSession{conn: conn, service: service, path: path}, nil
+}
+
+func (k *keyringSession) close() {
+	k.conn.Object(secretServiceName, k.path).Call("org.freedesktop.Secret.Session.Close", 0)
+	k.conn.Close()
+}
+
+func (k *keyringSession) defaultCollection() (dbus.ObjectPath, error) {
+	var collection dbus.ObjectPath
+	if err := k.service.Call(secretServiceIface+".ReadAlias", 0, "default").Store(&collection); err != nil {
+		return "", fmt.Errorf("failed to look up the default keyring: %w", err)
+	}
+
+	// Some services only expose the alias path itself
+	if collection == "/" {
+		collection = secretDefaultAlias
+	}
+
+	return collection, nil
+}
+
+// Returns the items belonging to the profile, unlocking them first when needed.
+func (k *keyringSession) search(profile string) ([]dbus.ObjectPath, error) {
+	var unlocked, locked []dbus.ObjectPath
+	if err := k.service.Call(secretServiceIface+".SearchItems", 0, keyringAttributes(profile)).Store(&unlocked, &locked); err != nil {
+		return nil, fmt.Errorf("failed to search keyring: %w", err)
+	}
+
+	if len(locked) > 0 {
+		if err := k.unlock(locked); err != nil {
+			return nil, err
+		}
+		unlocked = append(unlocked, locked...)
+	}
+
+	return unlocked, nil
+}
+
+func (k *keyringSession) unlock(objects []dbus.ObjectPath) error {
+	var unlocked []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := k.service.Call(secretServiceIface+".Unlock", 0, objects).Store(&unlocked, &prompt); err != nil {
+		return fmt.Errorf("failed to unlock keyring: %w", err)
+	}
+
+	return k.prompt(prompt)
+}
+
+// Runs a Secret Service prompt (e.g. the keyring unlock dialog) and waits for the user to finish it.
+func (k *keyringSession) prompt(prompt dbus.ObjectPath) error {
+	if prompt == "" || prompt == "/" {
+		return nil
+	}
+
+	if err := k.conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(prompt),
+		dbus.WithMatchInterface(secretPromptIface),
+		dbus.WithMatchMember("Completed"),
+	); err != nil {
+		return err
+	}
+
+	signals := make(chan *dbus.Signal, 1)
+	k.conn.Signal(signals)
+	defer k.conn.RemoveSignal(signals)
+
+	if err := k.conn.Object(secretServiceName, prompt).Call(secretPromptIface+".Prompt", 0, "").Err; err != nil {
+		return fmt.Errorf("failed to show keyring prompt: %w", err)
+	}
+
+	timeout := time.After(keyringPromptWait)
+	for {
+		select {
+		case sig := <-signals:
+			if sig.Path != prompt || sig.Name != secretPromptIface+".Completed" {
+				continue
+			}
+			if len(sig.Body) > 0 {
+				if dismissed, ok := sig.Body[0].(bool); ok && dismissed {
+					return errors.New("keyring prompt was dismissed")
+				}
+			}
+			return nil
+		case <-timeout:
+			return errors.New("timed out waiting for keyring prompt")
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package secretstore
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:dir=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// Starts a private session bus and points DBUS_SESSION_BUS_ADDRESS at it.
+func startSessionBus(t *testing.T) {
+	t.Helper()
+
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "session.conf")
+	if err := os.WriteFile(config, fmt.Appendf(nil, busConfig, dir), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(daemon, "--config-file="+config, "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", strings.TrimSpace(address))
+}
+
+const (
+	fakeCollectionPath = dbus.ObjectPath("/org/freedesktop/secrets/collection/login")
+	fakeSessionPath    = dbus.ObjectPath("/org/freedesktop/secrets/session/1")
+)
+
+// A stand-in Secret Service that keeps its items in memory and never prompts.
+type fakeSecretService struct {
+	conn  *dbus.Conn
+	mu    sync.Mutex
+	items map[dbus.ObjectPath]*fakeItem
+	next  int
+}
+
+type fakeItem struct {
+	service *fakeSecretService
+	path    dbus.ObjectPath
+	attrs   map[string]string
+	value   []byte
+}
+
+func startFakeSecretService(t *testing.T) *fakeSecretService {
+	t.Helper()
+	startSessionBus(t)
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	s := &fakeSecretService{conn: conn, items: map[dbus.ObjectPath]*fakeItem{}}
+	if err := conn.Export(s, secretServicePath, secretServiceIface); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Export(&fakeCollection{s}, fakeCollectionPath, secretCollectionIface); err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := conn.RequestName(secretServiceName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("failed to own %s: %v", secretServiceName, err)
+	}
+
+	return s
+}
+
+func (s *fakeSecretService) OpenSession(algorithm string, input dbus.Variant) (dbus.Variant, dbus.ObjectPath, *dbus.Error) {
+	return dbus.MakeVariant(""), fakeSessionPath, nil
+}
+
+func (s *fakeSecretService) ReadAlias(name string) (dbus.ObjectPath, *dbus.Error) {
+	return fakeCollectionPath, nil
+}
+
+func (s *fakeSecretService) Unlock(objects []dbus.ObjectPath) ([]dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	return objects, "/", nil
+}
+
+func (s *fakeSecretService) SearchItems(attrs map[string]string) ([]dbus.ObjectPath, []dbus.ObjectPath, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlocked := []dbus.ObjectPath{}
+	for path, item := range s.items {
+		if matchAttributes(item.attrs, attrs) {
+			unlocked = append(unlocked, path)
+		}
+	}
+	return unlocked, []dbus.ObjectPath{}, nil
+}
+
+func (s *fakeSecretService) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.items)
+}
+
+func matchAttributes(have, want map[string]string) bool {
+	for k, v := range want {
+		if have[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+type fakeCollection struct {
+	service *fakeSecretService
+}
+
+func (c *fakeCollection) CreateItem(props map[string]dbus.Variant, value secret, replace bool) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	attrs, ok := props[secretItemIface+".Attributes"].Value().(map[string]string)
+	if !ok {
+		return "", "", dbus.MakeFailedError(fmt.Errorf("missing item attributes"))
+	}
+
+	s := c.service
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if replace {
+		for path, item := range s.items {
+			if maps.Equal(item.attrs, attrs) {
+				item.value = value.Value
+				return path, "/", nil
+			}
+		}
+	}
+
+	s.next++
+	item := &fakeItem{service: s, path: dbus.ObjectPath(fmt.Sprintf("%s/%d", fakeCollectionPath, s.next)), attrs: attrs, value: value.Value}
+	if err := s.conn.Export(item, item.path, secretItemIface); err != nil {
+		return "", "", dbus.MakeFailedError(err)
+	}
+	s.items[item.path] = item
+
+	return item.path, "/", nil
+}
+
+func (i *fakeItem) GetSecret(session dbus.ObjectPath) (secret, *dbus.Error) {
+	i.service.mu.Lock()
+	defer i.service.mu.Unlock()
+
+	return secret{Session: session, Parameters: []byte{}, Value: i.value, ContentType: "application/json"}, nil
+}
+
+func (i *fakeItem) Delete() (dbus.ObjectPath, *dbus.Error) {
+	i.service.mu.Lock()
+	defer i.service.mu.Unlock()
+
+	delete(i.service.items, i.path)
+	i.service.conn.Export(nil, i.path, secretItemIface)
+	return "/", nil
+}
+
+func TestKeyringStore(t *testing.T) {
+	service := startFakeSecretService(t)
+
+	store := &keyringStore{}
+	path := filepath.Join(t.TempDir(), "default.json")
+	payload := []byte(`{"provider":"aws"}`)
+
+	if err := store.Save("default", path, payload); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	detected, err := Detect(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detected.Name() != Keyring {
+		t.Errorf("Detect() = %s, want %s", detected.Name(), Keyring)
+	}
+	if data, _ := os.ReadFile(path); bytes.Contains(data, payload) {
+		t.Errorf("profile file holds the payload: %s", data)
+	}
+
+	// Saving again replaces the item instead of adding another one
+	payload = []byte(`{"provider":"azure"}`)
+	if err := store.Save("default", path, payload); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if service.count() != 1 {
+		t.Errorf("keyring holds %d items, want 1", service.count())
+	}
+
+	got, err := store.Load("default", path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Errorf("Load() = %s, want %s", got, payload)
+	}
+
+	if _, err := store.Load("other", path); err == nil {
+		t.Error("Load() of another profile succeeded")
+	}
+
+	if err := store.Purge("default", path); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if service.count() != 0 {
+		t.Errorf("keyring holds %d items after purge, want 0", service.count())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("profile file still exists after purge: %v", err)
+	}
+}
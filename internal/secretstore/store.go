@@ -0,0 +1,113 @@
+package secretstore
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	File    = "file"
+	Age     = "age"
+	Keyring = "keyring"
+)
+
+// A Store persists the serialized credential of a profile. The profile file at path always exists
+// once saved so profiles can be listed, but depending on the backend it holds the plaintext payload,
+// an encrypted payload, or only a marker pointing at the OS keyring.
+type Store interface {
+	Name() string
+	Save(profile, path string, data []byte) error
+	Load(profile, path string) ([]byte, error)
+	Purge(profile, path string) error
+}
+
+func Names() []string {
+	return []string{File, Age, Keyring}
+}
+
+func New(name string) (Store, error) {
+	switch strings.ToLower(name) {
+	case File:
+		return &fileStore{}, nil
+	case Age:
+		return &ageStore{}, nil
+	case Keyring:
+		return &keyringStore{}, nil
+	default:
+		return nil, fmt.Errorf("unknown credential store %q (valid: %s)", name, strings.Join(Names(), ", "))
+	}
+}
+
+// Works out which backend wrote the profile file at path, so credentials stay readable
+// regardless of the store currently selected for writing.
+func Detect(path string) (Store, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(ageArmorHeader)) {
+		return &ageStore{}, nil
+	}
+
+	var m marker
+	if err := json.Unmarshal(data, &m); err == nil && m.Store == Keyring {
+		return &keyringStore{}, nil
+	}
+
+	return &fileStore{}, nil
+}
+
+// Content of the profile file when the payload itself lives elsewhere.
+type marker struct {
+	Store string `json:"store"`
+}
+
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0600)
+}
+
+// Overwrites the file with random bytes before unlinking it, so the secret doesn't linger in freed blocks.
+func Shred(path string) error {
+	if err := overwriteFile(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func overwriteFile(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	noise := make([]byte, info.Size())
+	if _, err := rand.Read(noise); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(noise, 0); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
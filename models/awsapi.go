@@ -4,4 +4,6 @@ type AWSCredential struct {
 	AccessKeyId     string `json:"accessKeyId"`
 	SecretAccessKey string `json:"secretAccessKey"`
 	Region          string `json:"region"`
+	// Only set for temporary credentials
+	SessionToken string `json:"sessionToken,omitempty"`
 }
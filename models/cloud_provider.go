@@ -1,8 +1,31 @@
 package models
 
+import (
+	"fmt"
+	"strings"
+)
+
 type CloudProvider string
 
 const (
 	AWS   CloudProvider = "AWS"
 	Azure CloudProvider = "Azure"
+	GCP   CloudProvider = "GCP"
 )
+
+func CloudProviderNames() []string {
+	return []string{ProviderAWS, ProviderAzure, ProviderGCP}
+}
+
+func ParseCloudProvider(s string) (CloudProvider, error) {
+	switch strings.ToLower(s) {
+	case ProviderAWS:
+		return AWS, nil
+	case ProviderAzure:
+		return Azure, nil
+	case ProviderGCP:
+		return GCP, nil
+	default:
+		return "", fmt.Errorf("invalid cloud provider %q (valid: %s)", s, strings.Join(CloudProviderNames(), ", "))
+	}
+}
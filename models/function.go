@@ -3,17 +3,39 @@ package models
 type Command string
 
 const (
-	Ps                  Command = "ps"
-	PsCreateCredential  Command = "create-cred"
-	PsGetCredential     Command = "get-cred"
-	PsInitCredential    Command = "init-cred"
-	PsLoginByCredential Command = "login-by-cred"
+	Ps                     Command = "ps"
+	PsCreateCredential     Command = "create-cred"
+	PsGetCredential        Command = "get-cred"
+	PsInitCredential       Command = "init-cred"
+	PsLoginByCredential    Command = "login-by-cred"
+	PsExec                 Command = "exec"
+	PsProfiles             Command = "profiles"
+	PsProfilesList         Command = "list"
+	PsProfilesShow         Command = "show"
+	PsProfilesDelete       Command = "delete"
+	PsProfilesUse          Command = "use"
+	PsStatus               Command = "status"
+	PsPurgeCredential      Command = "purge-cred"
+	PsRules                Command = "rules"
+	PsAWSCredentialProcess Command = "aws-credential-process"
+	PsDirenv               Command = "direnv"
 )
 
 var CommandDescriptions = map[Command]string{
-	Ps:                  "Interact with PluralSight resources",
-	PsCreateCredential:  "Creates credential",
-	PsGetCredential:     "Get credential",
-	PsInitCredential:    "Initialize credential in every consumed environment (e.g. Terraform's variables.tf, etc.)",
-	PsLoginByCredential: "Log in to appropriate cloud by credential",
+	Ps:                     "Interact with PluralSight resources",
+	PsCreateCredential:     "Creates credential",
+	PsGetCredential:        "Get credential",
+	PsInitCredential:       "Initialize credential in every consumed environment (e.g. Terraform's variables.tf, etc.)",
+	PsLoginByCredential:    "Log in to appropriate cloud by credential",
+	PsExec:                 "Run a command with the stored credential injected as environment variables",
+	PsProfiles:             "Manage named credential profiles",
+	PsProfilesList:         "List stored credential profiles",
+	PsProfilesShow:         "Show the credential stored in a profile",
+	PsProfilesDelete:       "Delete a credential profile",
+	PsProfilesUse:          "Set the default credential profile",
+	PsStatus:               "Show the provider and remaining lifetime of the stored sandbox",
+	PsPurgeCredential:      "Securely remove stored credentials",
+	PsRules:                "Print the built-in extraction rules for the sandbox page",
+	PsAWSCredentialProcess: "Serve the stored AWS credential to the AWS CLI and SDKs as a credential_process",
+	PsDirenv:               "Print the stored credential for direnv, reloading whenever the profile changes",
 }
@@ -0,0 +1,8 @@
+package models
+
+type GCPCredential struct {
+	ProjectID         string `json:"projectId"`
+	ServiceAccountKey string `json:"serviceAccountKey"`
+	// Where the service account key is written for tools that only accept a file path
+	KeyFile string `json:"keyFile"`
+}
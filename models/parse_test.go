@@ -0,0 +1,102 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStdOutFormat(t *testing.T) {
+	for _, name := range StdOutFormatNames() {
+		if _, err := ParseStdOutFormat(name); err != nil {
+			t.Errorf("ParseStdOutFormat(%q) error = %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		in      string
+		want    StdOutFormat
+		wantErr string
+	}{
+		{in: "ENV", want: LINUX_ENV_VAR},
+		{in: "Json", want: JSON},
+		{in: "github", want: GITHUB_ACTIONS},
+		{in: "xml", wantErr: `invalid output format "xml" (valid: azure-pipelines, cmd, dotenv, env, fish, github, json, k8s-secret, powershell, table, tfvars, yaml)`},
+		{in: "", wantErr: `invalid output format ""`},
+		{in: "LINUX_ENV_VAR", wantErr: `invalid output format "LINUX_ENV_VAR"`},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseStdOutFormat(tt.in)
+		checkParse(t, "ParseStdOutFormat", tt.in, got, tt.want, err, tt.wantErr)
+	}
+}
+
+func TestParseCredentialScope(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    CredentialScope
+		wantErr string
+	}{
+		{in: "full", want: ScopeFull},
+		{in: "Terraform", want: ScopeTerraform},
+		{in: "tf", wantErr: `invalid credential scope "tf" (valid: full, terraform)`},
+		{in: "", wantErr: `invalid credential scope ""`},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCredentialScope(tt.in)
+		checkParse(t, "ParseCredentialScope", tt.in, got, tt.want, err, tt.wantErr)
+	}
+}
+
+func TestParseCloudProvider(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    CloudProvider
+		wantErr string
+	}{
+		{in: "aws", want: AWS},
+		{in: "AZURE", want: Azure},
+		{in: "gcp", want: GCP},
+		{in: "google", wantErr: `invalid cloud provider "google" (valid: aws, azure, gcp)`},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCloudProvider(tt.in)
+		checkParse(t, "ParseCloudProvider", tt.in, got, tt.want, err, tt.wantErr)
+	}
+}
+
+func TestParseSortOrder(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    SortOrder
+		wantErr string
+	}{
+		{in: "schema", want: SortBySchema},
+		{in: "NAME", want: SortByName},
+		{in: "value", wantErr: `invalid sort order "value" (valid: schema, name)`},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSortOrder(tt.in)
+		checkParse(t, "ParseSortOrder", tt.in, got, tt.want, err, tt.wantErr)
+	}
+}
+
+func checkParse[T comparable](t *testing.T, fn, in string, got, want T, err error, wantErr string) {
+	t.Helper()
+
+	if wantErr != "" {
+		if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("%s(%q) error = %v, want %q", fn, in, err, wantErr)
+		}
+		return
+	}
+	if err != nil {
+		t.Errorf("%s(%q) error = %v", fn, in, err)
+	}
+	if got != want {
+		t.Errorf("%s(%q) = %v, want %v", fn, in, got, want)
+	}
+}
@@ -1,22 +1,81 @@
 package models
 
+import (
+	"fmt"
+	"strings"
+)
+
 type StdOutFormat string
 
 const (
-	JSON          StdOutFormat = "JSON"
-	TABLE         StdOutFormat = "TABLE"
-	LINUX_ENV_VAR StdOutFormat = "LINUX_ENV_VAR"
+	JSON            StdOutFormat = "JSON"
+	TABLE           StdOutFormat = "TABLE"
+	LINUX_ENV_VAR   StdOutFormat = "LINUX_ENV_VAR"
+	DOTENV          StdOutFormat = "DOTENV"
+	FISH            StdOutFormat = "FISH"
+	POWERSHELL      StdOutFormat = "POWERSHELL"
+	CMD             StdOutFormat = "CMD"
+	YAML            StdOutFormat = "YAML"
+	TFVARS          StdOutFormat = "TFVARS"
+	K8S_SECRET      StdOutFormat = "K8S_SECRET"
+	GITHUB_ACTIONS  StdOutFormat = "GITHUB_ACTIONS"
+	AZURE_PIPELINES StdOutFormat = "AZURE_PIPELINES"
 )
 
-func ParseStdOutFormat(s string) StdOutFormat {
-	switch s {
+func StdOutFormatNames() []string {
+	return []string{"azure-pipelines", "cmd", "dotenv", "env", "fish", "github", "json", "k8s-secret", "powershell", "table", "tfvars", "yaml"}
+}
+
+func ParseStdOutFormat(s string) (StdOutFormat, error) {
+	switch strings.ToLower(s) {
 	case "json":
-		return JSON
+		return JSON, nil
 	case "table":
-		return TABLE
+		return TABLE, nil
 	case "env":
-		return LINUX_ENV_VAR
+		return LINUX_ENV_VAR, nil
+	case "dotenv":
+		return DOTENV, nil
+	case "fish":
+		return FISH, nil
+	case "powershell":
+		return POWERSHELL, nil
+	case "cmd":
+		return CMD, nil
+	case "yaml":
+		return YAML, nil
+	case "tfvars":
+		return TFVARS, nil
+	case "k8s-secret":
+		return K8S_SECRET, nil
+	case "github":
+		return GITHUB_ACTIONS, nil
+	case "azure-pipelines":
+		return AZURE_PIPELINES, nil
+	default:
+		return "", fmt.Errorf("invalid output format %q (valid: %s)", s, strings.Join(StdOutFormatNames(), ", "))
+	}
+}
+
+type SortOrder string
+
+const (
+	// Keys in the order the credential type defines them
+	SortBySchema SortOrder = "schema"
+	SortByName   SortOrder = "name"
+)
+
+func SortOrderNames() []string {
+	return []string{string(SortBySchema), string(SortByName)}
+}
+
+func ParseSortOrder(s string) (SortOrder, error) {
+	switch SortOrder(strings.ToLower(s)) {
+	case SortBySchema:
+		return SortBySchema, nil
+	case SortByName:
+		return SortByName, nil
 	default:
-		return LINUX_ENV_VAR
+		return "", fmt.Errorf("invalid sort order %q (valid: %s)", s, strings.Join(SortOrderNames(), ", "))
 	}
-}
\ No newline at end of file
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 type CredentialScope string
 
@@ -9,15 +13,27 @@ const (
 	ScopeTerraform CredentialScope = "terraform"
 )
 
-func ParseCredentialScope(s string) CredentialScope {
-	switch s {
+func CredentialScopeNames() []string {
+	return []string{string(ScopeFull), string(ScopeTerraform)}
+}
+
+func ParseCredentialScope(s string) (CredentialScope, error) {
+	switch strings.ToLower(s) {
+	case "full":
+		return ScopeFull, nil
 	case "terraform":
-		return ScopeTerraform
+		return ScopeTerraform, nil
 	default:
-		return ScopeFull
+		return "", fmt.Errorf("invalid credential scope %q (valid: %s)", s, strings.Join(CredentialScopeNames(), ", "))
 	}
 }
 
+const (
+	ProviderAWS   = "aws"
+	ProviderAzure = "azure"
+	ProviderGCP   = "gcp"
+)
+
 type SandboxCredential interface {
 	Provider() string
 	IsExpired() bool
@@ -25,11 +41,48 @@ type SandboxCredential interface {
 	ToEnvMap() map[string]string
 	ToTerraformEnvMap() map[string]string
 	ToScopedEnvMap(scope CredentialScope) map[string]string
+	// Names of the environment variables in the order they are printed
+	EnvKeys() []string
+	// Names of the environment variables holding secrets, masked when printed to a terminal
+	SecretEnvKeys() []string
+}
+
+// Tracks when a sandbox is torn down by PluralSight. A zero Expiration means the expiry is unknown.
+type SandboxLifetime struct {
+	Expiration time.Time `json:"expiration,omitzero"`
+}
+
+func (l *SandboxLifetime) ExpiresAt() time.Time {
+	return l.Expiration
+}
+
+func (l *SandboxLifetime) IsExpired() bool {
+	return !l.Expiration.IsZero() && !time.Now().Before(l.Expiration)
+}
+
+var (
+	_ SandboxCredential = (*PsAwsCredential)(nil)
+	_ SandboxCredential = (*PsAzureCredential)(nil)
+	_ SandboxCredential = (*PsGcpCredential)(nil)
+)
+
+// Returns an empty credential of the concrete type matching the provider discriminator.
+func NewSandboxCredential(provider string) (SandboxCredential, error) {
+	switch provider {
+	case ProviderAWS:
+		return &PsAwsCredential{}, nil
+	case ProviderAzure:
+		return &PsAzureCredential{}, nil
+	case ProviderGCP:
+		return &PsGcpCredential{}, nil
+	default:
+		return nil, fmt.Errorf("unknown sandbox credential provider %q", provider)
+	}
 }
 
 type PsAwsCredential struct {
-	SandboxCredential
 	AWSCredential
+	SandboxLifetime
 
 	SandboxURL string `json:"sandboxUrl"`
 	User       string `json:"user"`
@@ -37,7 +90,15 @@ type PsAwsCredential struct {
 }
 
 func (a *PsAwsCredential) Provider() string {
-	return "aws"
+	return ProviderAWS
+}
+
+func (c *PsAwsCredential) EnvKeys() []string {
+	return []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_REGION", "AWS_SANDBOX_URL", "AWS_USERNAME", "AWS_PASSWORD"}
+}
+
+func (c *PsAwsCredential) SecretEnvKeys() []string {
+	return []string{"AWS_SECRET_ACCESS_KEY", "AWS_PASSWORD"}
 }
 
 func (c *PsAwsCredential) ToEnvMap() map[string]string {
@@ -67,8 +128,8 @@ func (c *PsAwsCredential) ToScopedEnvMap(scope CredentialScope) map[string]strin
 }
 
 type PsAzureCredential struct {
-	SandboxCredential
 	ARMCredential
+	SandboxLifetime
 
 	SandboxURL                    string `json:"sandboxUrl"`
 	TenantName                    string `json:"tenantName"`
@@ -79,7 +140,18 @@ type PsAzureCredential struct {
 }
 
 func (a *PsAzureCredential) Provider() string {
-	return "azure"
+	return ProviderAzure
+}
+
+func (c *PsAzureCredential) EnvKeys() []string {
+	return []string{
+		"ARM_SUBSCRIPTION_ID", "ARM_TENANT_ID", "ARM_CLIENT_ID", "ARM_CLIENT_SECRET", "ARM_SANDBOX_URL",
+		"ARM_TENANT_NAME", "ARM_USERNAME", "ARM_PASSWORD", "ARM_RESOURCE_GROUP", "ARM_RESOURCE_PROVIDER_REGISTRATIONS",
+	}
+}
+
+func (c *PsAzureCredential) SecretEnvKeys() []string {
+	return []string{"ARM_CLIENT_SECRET", "ARM_PASSWORD"}
 }
 
 func (c *PsAzureCredential) ToEnvMap() map[string]string {
@@ -113,3 +185,58 @@ func (c *PsAzureCredential) ToScopedEnvMap(scope CredentialScope) map[string]str
 	}
 	return c.ToEnvMap()
 }
+
+type PsGcpCredential struct {
+	GCPCredential
+	SandboxLifetime
+
+	SandboxURL string `json:"sandboxUrl"`
+	User       string `json:"user"`
+	Password   string `json:"password"`
+}
+
+func (a *PsGcpCredential) Provider() string {
+	return ProviderGCP
+}
+
+func (c *PsGcpCredential) EnvKeys() []string {
+	return []string{
+		"GOOGLE_APPLICATION_CREDENTIALS", "GOOGLE_CREDENTIALS", "GOOGLE_PROJECT", "GOOGLE_CLOUD_PROJECT",
+		"CLOUDSDK_CORE_PROJECT", "GCP_SANDBOX_URL", "GCP_USERNAME", "GCP_PASSWORD",
+	}
+}
+
+func (c *PsGcpCredential) SecretEnvKeys() []string {
+	return []string{"GOOGLE_CREDENTIALS", "GCP_PASSWORD"}
+}
+
+// GOOGLE_APPLICATION_CREDENTIALS is left out without a key file: set but empty, gcloud and the client libraries fail.
+func (c *PsGcpCredential) ToEnvMap() map[string]string {
+	env := map[string]string{
+		"GOOGLE_CREDENTIALS":    c.ServiceAccountKey,
+		"GOOGLE_PROJECT":        c.ProjectID,
+		"GOOGLE_CLOUD_PROJECT":  c.ProjectID,
+		"CLOUDSDK_CORE_PROJECT": c.ProjectID,
+		"GCP_SANDBOX_URL":       c.SandboxURL,
+		"GCP_USERNAME":          c.User,
+		"GCP_PASSWORD":          c.Password,
+	}
+	if c.KeyFile != "" {
+		env["GOOGLE_APPLICATION_CREDENTIALS"] = c.KeyFile
+	}
+	return env
+}
+
+func (c *PsGcpCredential) ToTerraformEnvMap() map[string]string {
+	return map[string]string{
+		"GOOGLE_CREDENTIALS": c.ServiceAccountKey,
+		"GOOGLE_PROJECT":     c.ProjectID,
+	}
+}
+
+func (c *PsGcpCredential) ToScopedEnvMap(scope CredentialScope) map[string]string {
+	if scope == ScopeTerraform {
+		return c.ToTerraformEnvMap()
+	}
+	return c.ToEnvMap()
+}
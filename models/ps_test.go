@@ -0,0 +1,37 @@
+package models
+
+import "testing"
+
+func TestPsGcpCredentialToEnvMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyFile  string
+		wantFile bool
+	}{
+		{name: "plaintext store with a key file", keyFile: "/home/u/.config/bear/ps/gcp/lab.json", wantFile: true},
+		{name: "encrypted store without a key file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred := &PsGcpCredential{GCPCredential: GCPCredential{
+				ServiceAccountKey: `{"type":"service_account"}`,
+				ProjectID:         "playground-s-11",
+				KeyFile:           tt.keyFile,
+			}}
+
+			for scope, env := range map[CredentialScope]map[string]string{
+				ScopeFull:      cred.ToEnvMap(),
+				ScopeTerraform: cred.ToScopedEnvMap(ScopeTerraform),
+			} {
+				value, ok := env["GOOGLE_APPLICATION_CREDENTIALS"]
+				if want := tt.wantFile && scope == ScopeFull; ok != want || (ok && value != tt.keyFile) {
+					t.Errorf("%s: GOOGLE_APPLICATION_CREDENTIALS = %q (set %t), want set %t", scope, value, ok, want)
+				}
+				if env["GOOGLE_CREDENTIALS"] != cred.ServiceAccountKey || env["GOOGLE_PROJECT"] != "playground-s-11" {
+					t.Errorf("%s: key or project missing from %v", scope, env)
+				}
+			}
+		})
+	}
+}
@@ -6,8 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"os"
 	"reflect"
+	"slices"
+	"sort"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 func padRight(s string, n int) string {
@@ -15,6 +21,11 @@ func padRight(s string, n int) string {
 }
 
 func PrintTable(data any, hiddenFields ...string) {
+	fprintTable(os.Stdout, data, nil, hiddenFields...)
+}
+
+// Columns follow order when given, otherwise struct field order or, for maps, alphabetical order.
+func fprintTable(w io.Writer, data any, order []string, hiddenFields ...string) {
 	hidden := map[string]bool{}
 	for _, h := range hiddenFields {
 		hidden[h] = true
@@ -34,6 +45,13 @@ func PrintTable(data any, hiddenFields ...string) {
 
 	var rows []map[string]string
 	colNames := map[string]bool{}
+	var cols []string
+	addCol := func(name string) {
+		if !colNames[name] {
+			colNames[name] = true
+			cols = append(cols, name)
+		}
+	}
 
 	for i := 0; i < v.Len(); i++ {
 		item := v.Index(i).Interface()
@@ -53,14 +71,18 @@ func PrintTable(data any, hiddenFields ...string) {
 				}
 
 				val := fmt.Sprintf("%v", rv.Field(f).Interface())
-				colNames[name] = true
+				addCol(name)
 				row[name] = val
 			}
 		}
 
 		// Map support
 		if rv.Kind() == reflect.Map {
-			for _, key := range rv.MapKeys() {
+			keys := rv.MapKeys()
+			sort.Slice(keys, func(a, b int) bool {
+				return fmt.Sprintf("%v", keys[a].Interface()) < fmt.Sprintf("%v", keys[b].Interface())
+			})
+			for _, key := range keys {
 				name := fmt.Sprintf("%v", key.Interface())
 
 				if hidden[name] {
@@ -68,7 +90,7 @@ func PrintTable(data any, hiddenFields ...string) {
 				}
 
 				val := fmt.Sprintf("%v", rv.MapIndex(key).Interface())
-				colNames[name] = true
+				addCol(name)
 				row[name] = val
 			}
 		}
@@ -76,12 +98,10 @@ func PrintTable(data any, hiddenFields ...string) {
 		rows = append(rows, row)
 	}
 
-	// Convert colNames to slice
-	cols := make([]string, 0, len(colNames))
-	for c := range colNames {
-		if !hidden[c] {
-			cols = append(cols, c)
-		}
+	if order != nil {
+		cols = slices.DeleteFunc(slices.Clone(order), func(c string) bool {
+			return hidden[c]
+		})
 	}
 
 	// Compute widths
@@ -99,21 +119,21 @@ func PrintTable(data any, hiddenFields ...string) {
 
 	// Print header
 	for _, c := range cols {
-		fmt.Print(padRight(c, width[c]+2))
+		fmt.Fprint(w, padRight(c, width[c]+2))
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	for _, c := range cols {
-		fmt.Print(strings.Repeat("-", width[c]) + "  ")
+		fmt.Fprint(w, strings.Repeat("-", width[c])+"  ")
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	// Print rows
 	for _, row := range rows {
 		for _, c := range cols {
-			fmt.Print(padRight(row[c], width[c]+2))
+			fmt.Fprint(w, padRight(row[c], width[c]+2))
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
 }
 
@@ -134,30 +154,291 @@ func PrintJSON(body io.Reader) {
 }
 
 func PrintJSONText(data any, hiddenFields ...string) {
+	fprintJSONText(os.Stdout, data, hiddenFields...)
+}
+
+func fprintJSONText(w io.Writer, data any, hiddenFields ...string) {
+	if len(hiddenFields) > 0 {
+		data = withoutJSONFields(data, hiddenFields)
+	}
+
 	b, _ := json.MarshalIndent(data, "", "  ")
-	fmt.Println(string(b))
+	fmt.Fprintln(w, string(b))
+}
+
+// Round-trips data through its JSON form so hidden members can be dropped from structs and maps alike.
+func withoutJSONFields(data any, hiddenFields []string) any {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return data
+	}
+
+	objects := []any{generic}
+	if items, ok := generic.([]any); ok {
+		objects = items
+	}
+	for _, o := range objects {
+		if m, ok := o.(map[string]any); ok {
+			for _, h := range hiddenFields {
+				delete(m, h)
+			}
+		}
+	}
+
+	return generic
+}
+
+// Prints the map as a JSON object whose members follow keys, which json.Marshal cannot do for a map.
+func FprintOrderedJSON(w io.Writer, data map[string]string, keys []string) error {
+	var b strings.Builder
+	b.WriteString("{")
+	for i, k := range keys {
+		key, err := json.Marshal(k)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(data[k])
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, "\n  %s: %s", key, value)
+	}
+	if len(keys) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("}")
+
+	fmt.Fprintln(w, b.String())
+	return nil
 }
 
+// Prints the variables in alphabetical order, leaving out the hidden ones.
 func PrintLinuxEnvVar(data any, hiddenFields ...string) error {
 	dataMap, ok := data.(map[string]string)
 	if !ok {
 		return fmt.Errorf("expected map[string]string")
 	}
-	for k, v := range dataMap {
-		fmt.Printf("export %s=%q\n", k, v)
+	keys := slices.DeleteFunc(sortedKeys(dataMap), func(k string) bool {
+		return slices.Contains(hiddenFields, k)
+	})
+	return FprintLinuxEnvVar(os.Stdout, dataMap, keys)
+}
+
+// The output is meant for eval, so values are single-quoted: Go's %q would still let $ and ` expand.
+func FprintLinuxEnvVar(w io.Writer, data map[string]string, keys []string) error {
+	for _, k := range keys {
+		fmt.Fprintf(w, "export %s=%s\n", k, QuoteShell(data[k]))
+	}
+	return nil
+}
+
+func FprintDotenv(w io.Writer, data map[string]string, keys []string) error {
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s=%s\n", k, quoteDotenv(data[k]))
+	}
+	return nil
+}
+
+func FprintFishEnvVar(w io.Writer, data map[string]string, keys []string) error {
+	for _, k := range keys {
+		fmt.Fprintf(w, "set -gx %s %s\n", k, quoteFish(data[k]))
+	}
+	return nil
+}
+
+func FprintPowerShellEnvVar(w io.Writer, data map[string]string, keys []string) error {
+	for _, k := range keys {
+		fmt.Fprintf(w, "$env:%s = %s\n", k, quotePowerShell(data[k]))
+	}
+	return nil
+}
+
+func FprintCmdEnvVar(w io.Writer, data map[string]string, keys []string) error {
+	// Check every value first so a failure never leaves half a script on stdout
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		line, err := quoteCmd(k, data[k])
+		if err != nil {
+			return err
+		}
+		lines = append(lines, line)
+	}
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
 	}
 	return nil
 }
 
+func FprintYAML(w io.Writer, data map[string]string, keys []string) error {
+	b, err := yaml.Marshal(yamlMapping(data, keys))
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(w, string(b))
+	return nil
+}
+
+func FprintTFVars(w io.Writer, data map[string]string, keys []string) error {
+	names := make([]string, len(keys))
+	width := 0
+	for i, k := range keys {
+		names[i] = toSnakeCase(k)
+		width = max(width, len(names[i]))
+	}
+	for i, k := range keys {
+		fmt.Fprintf(w, "%s = %s\n", padRight(names[i], width), quoteHCL(data[k]))
+	}
+	return nil
+}
+
+// Chooses which keys of a map are printed and in which order.
+type PrintOptions struct {
+	// Schema order of the keys; keys it does not list follow alphabetically
+	Order []string
+	// Sorting by name ignores Order and prints every key alphabetically
+	SortBy models.SortOrder
+	// Print only these keys, in this order, matched case-insensitively
+	Columns []string
+	// Keys whose values are replaced by MaskedValue
+	Masked []string
+	// Keys holding secrets, which the pipeline formats tell CI to mask
+	Secrets []string
+	// Where the github format writes the variables; the masks go to the output itself
+	GitHubEnv io.Writer
+	// Metadata of the k8s-secret format
+	KubernetesSecret KubernetesSecretOptions
+}
+
+const MaskedValue = "********"
+
+// Returns a copy of data with the masked keys redacted. Empty values stay empty so a missing secret still shows.
+func (o PrintOptions) mask(data map[string]string) map[string]string {
+	if len(o.Masked) == 0 {
+		return data
+	}
+
+	masked := maps.Clone(data)
+	for _, k := range o.Masked {
+		if masked[k] != "" {
+			masked[k] = MaskedValue
+		}
+	}
+	return masked
+}
+
+func (o PrintOptions) keys(data map[string]string) ([]string, error) {
+	if len(o.Columns) > 0 {
+		keys := make([]string, 0, len(o.Columns))
+		for _, c := range o.Columns {
+			key, ok := findKey(data, c)
+			if !ok {
+				return nil, fmt.Errorf("unknown column %q (valid: %s)", c, strings.Join(sortedKeys(data), ", "))
+			}
+			keys = append(keys, key)
+		}
+		return keys, nil
+	}
+
+	if o.SortBy == models.SortByName {
+		return sortedKeys(data), nil
+	}
+
+	keys := make([]string, 0, len(data))
+	for _, k := range o.Order {
+		if _, ok := data[k]; ok && !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range sortedKeys(data) {
+		if !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func findKey(data map[string]string, name string) (string, bool) {
+	if _, ok := data[name]; ok {
+		return name, true
+	}
+	for k := range data {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// Prints data to stdout with the default options, reporting a failure on stderr.
 func PrintStdOut(data any, stdOutFormat models.StdOutFormat, hiddenFields ...string) {
+	if err := FprintStdOut(os.Stdout, data, stdOutFormat, PrintOptions{}, hiddenFields...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func FprintStdOut(w io.Writer, data any, stdOutFormat models.StdOutFormat, opts PrintOptions, hiddenFields ...string) error {
+	dataMap, ok := data.(map[string]string)
+	if !ok {
+		// Only tables and JSON can print arbitrary values
+		switch stdOutFormat {
+		case models.TABLE:
+			fprintTable(w, data, nil, hiddenFields...)
+			return nil
+		case models.JSON:
+			fprintJSONText(w, data, hiddenFields...)
+			return nil
+		default:
+			return fmt.Errorf("expected map[string]string")
+		}
+	}
+
+	keys, err := opts.keys(dataMap)
+	if err != nil {
+		return err
+	}
+	keys = slices.DeleteFunc(keys, func(k string) bool {
+		return slices.Contains(hiddenFields, k)
+	})
+	dataMap = opts.mask(dataMap)
+
 	switch stdOutFormat {
 	case models.TABLE:
-		PrintTable(data, hiddenFields...)
+		fprintTable(w, dataMap, keys)
 	case models.JSON:
-		PrintJSONText(data, hiddenFields...)
+		return FprintOrderedJSON(w, dataMap, keys)
 	case models.LINUX_ENV_VAR:
-		PrintLinuxEnvVar(data, hiddenFields...)
+		return FprintLinuxEnvVar(w, dataMap, keys)
+	case models.DOTENV:
+		return FprintDotenv(w, dataMap, keys)
+	case models.FISH:
+		return FprintFishEnvVar(w, dataMap, keys)
+	case models.POWERSHELL:
+		return FprintPowerShellEnvVar(w, dataMap, keys)
+	case models.CMD:
+		return FprintCmdEnvVar(w, dataMap, keys)
+	case models.YAML:
+		return FprintYAML(w, dataMap, keys)
+	case models.TFVARS:
+		return FprintTFVars(w, dataMap, keys)
+	case models.K8S_SECRET:
+		return FprintKubernetesSecret(w, dataMap, keys, opts.KubernetesSecret)
+	case models.GITHUB_ACTIONS:
+		if opts.GitHubEnv == nil {
+			return fmt.Errorf("no $GITHUB_ENV file to write the variables to")
+		}
+		return FprintGitHubActions(w, opts.GitHubEnv, dataMap, keys, opts.Secrets)
+	case models.AZURE_PIPELINES:
+		return FprintAzurePipelines(w, dataMap, keys, opts.Secrets)
 	default:
 		break
 	}
+	return nil
 }
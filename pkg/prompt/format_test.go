@@ -0,0 +1,266 @@
+package prompt
+
+import (
+	"bear_cli/models"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Evaluates the exports the way `eval "$(bear ps get-cred)"` does and reads each value back.
+func TestFprintLinuxEnvVarEval(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh is not installed")
+	}
+
+	tests := map[string]string{
+		"dollar":    "pa$$word $HOME ${USER}",
+		"backticks": "`id` $(id)",
+		"double":    `say "hi"`,
+		"backslash": `C:\temp\n \\ \`,
+		"single":    "it's 'quoted'",
+		"newline":   "line one\nline two\n",
+		"empty":     "",
+	}
+
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			var script strings.Builder
+			if err := FprintLinuxEnvVar(&script, map[string]string{"VALUE": value}, []string{"VALUE"}); err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := exec.Command(sh, "-c", `eval "$1" && printf '%s' "$VALUE"`, "sh", script.String()).Output()
+			if err != nil {
+				t.Fatalf("eval %q: %v", script.String(), err)
+			}
+			if string(out) != value {
+				t.Errorf("eval %q gave %q, want %q", script.String(), out, value)
+			}
+		})
+	}
+}
+
+func TestPrintOptionsKeys(t *testing.T) {
+	data := map[string]string{"AWS_SECRET_ACCESS_KEY": "s", "AWS_ACCESS_KEY_ID": "a", "AWS_REGION": "r", "EXTRA": "x", "AWS_SESSION_TOKEN": "t"}
+	order := []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_REGION", "NOT_IN_DATA"}
+
+	tests := []struct {
+		name    string
+		opts    PrintOptions
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "schema order",
+			opts: PrintOptions{Order: order},
+			want: []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_REGION", "EXTRA"},
+		},
+		{
+			name: "keys missing from the schema follow alphabetically",
+			opts: PrintOptions{Order: []string{"AWS_REGION"}},
+			want: []string{"AWS_REGION", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "EXTRA"},
+		},
+		{
+			name: "no schema",
+			want: []string{"AWS_ACCESS_KEY_ID", "AWS_REGION", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "EXTRA"},
+		},
+		{
+			name: "sort by name ignores the schema",
+			opts: PrintOptions{Order: order, SortBy: models.SortByName},
+			want: []string{"AWS_ACCESS_KEY_ID", "AWS_REGION", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "EXTRA"},
+		},
+		{
+			name: "columns in the order given, case-insensitively",
+			opts: PrintOptions{Order: order, SortBy: models.SortByName, Columns: []string{"aws_region", "AWS_ACCESS_KEY_ID"}},
+			want: []string{"AWS_REGION", "AWS_ACCESS_KEY_ID"},
+		},
+		{
+			name:    "unknown column",
+			opts:    PrintOptions{Columns: []string{"AWS_REGION", "AWS_PROFILE"}},
+			wantErr: `unknown column "AWS_PROFILE" (valid: AWS_ACCESS_KEY_ID, AWS_REGION, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, EXTRA)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.opts.keys(data)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("keys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFprintTableOrder(t *testing.T) {
+	data := map[string]string{"B": "2", "A": "1", "C": "3"}
+
+	tests := []struct {
+		name   string
+		order  []string
+		hidden []string
+		want   string
+	}{
+		{name: "maps default to alphabetical columns", want: "A  B  C  \n-  -  -  \n1  2  3  \n"},
+		{name: "columns follow the order", order: []string{"C", "A", "B"}, want: "C  A  B  \n-  -  -  \n3  1  2  \n"},
+		{name: "hidden columns are left out", order: []string{"C", "A", "B"}, hidden: []string{"A"}, want: "C  B  \n-  -  \n3  2  \n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			fprintTable(&out, data, tt.order, tt.hidden...)
+			if out.String() != tt.want {
+				t.Errorf("table =\n%s\nwant\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFprintStdOutOrder(t *testing.T) {
+	data := map[string]string{"AWS_SECRET_ACCESS_KEY": "s", "AWS_ACCESS_KEY_ID": "a", "EXTRA": "x"}
+	opts := PrintOptions{Order: []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"}}
+
+	tests := []struct {
+		format models.StdOutFormat
+		want   string
+	}{
+		{format: models.TABLE, want: "AWS_ACCESS_KEY_ID  AWS_SECRET_ACCESS_KEY  EXTRA  \n"},
+		{format: models.JSON, want: "{\n  \"AWS_ACCESS_KEY_ID\": \"a\",\n  \"AWS_SECRET_ACCESS_KEY\": \"s\",\n  \"EXTRA\": \"x\"\n}\n"},
+		{format: models.DOTENV, want: "AWS_ACCESS_KEY_ID='a'\nAWS_SECRET_ACCESS_KEY='s'\nEXTRA='x'\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var out strings.Builder
+			if err := FprintStdOut(&out, data, tt.format, opts); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(out.String(), tt.want) {
+				t.Errorf("output =\n%s\nwant it to start with\n%s", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintOptionsMask(t *testing.T) {
+	data := map[string]string{"AWS_ACCESS_KEY_ID": "AKIAEXAMPLE", "AWS_SECRET_ACCESS_KEY": "s3cr3t", "AWS_PASSWORD": ""}
+
+	tests := []struct {
+		name   string
+		masked []string
+		want   map[string]string
+	}{
+		{name: "nothing masked", want: data},
+		{
+			name:   "secrets masked",
+			masked: []string{"AWS_SECRET_ACCESS_KEY", "AWS_PASSWORD"},
+			want:   map[string]string{"AWS_ACCESS_KEY_ID": "AKIAEXAMPLE", "AWS_SECRET_ACCESS_KEY": MaskedValue, "AWS_PASSWORD": ""},
+		},
+		{
+			name:   "masked key missing from the data",
+			masked: []string{"AWS_SESSION_TOKEN"},
+			want:   map[string]string{"AWS_ACCESS_KEY_ID": "AKIAEXAMPLE", "AWS_SECRET_ACCESS_KEY": "s3cr3t", "AWS_PASSWORD": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PrintOptions{Masked: tt.masked}.mask(data)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("mask = %v, want %v", got, tt.want)
+			}
+			if data["AWS_SECRET_ACCESS_KEY"] != "s3cr3t" {
+				t.Error("mask changed the caller's map")
+			}
+		})
+	}
+}
+
+func TestFprintStdOutMasking(t *testing.T) {
+	data := map[string]string{"AWS_ACCESS_KEY_ID": "AKIAEXAMPLE", "AWS_SECRET_ACCESS_KEY": "s3cr3t", "AWS_PASSWORD": "pa55word"}
+	secrets := []string{"AWS_SECRET_ACCESS_KEY", "AWS_PASSWORD"}
+
+	tests := []struct {
+		name        string
+		format      models.StdOutFormat
+		masked      []string
+		hidden      []string
+		wantPresent []string
+		wantAbsent  []string
+	}{
+		{
+			name:        "masked table",
+			format:      models.TABLE,
+			masked:      secrets,
+			wantPresent: []string{"AKIAEXAMPLE", "AWS_SECRET_ACCESS_KEY", MaskedValue},
+			wantAbsent:  []string{"s3cr3t", "pa55word"},
+		},
+		{
+			name:        "masked json",
+			format:      models.JSON,
+			masked:      secrets,
+			wantPresent: []string{`"AWS_SECRET_ACCESS_KEY": "` + MaskedValue + `"`},
+			wantAbsent:  []string{"s3cr3t", "pa55word"},
+		},
+		{
+			name:        "masked env",
+			format:      models.LINUX_ENV_VAR,
+			masked:      secrets,
+			wantPresent: []string{"export AWS_PASSWORD='" + MaskedValue + "'"},
+			wantAbsent:  []string{"s3cr3t", "pa55word"},
+		},
+		{
+			name:        "revealed table",
+			format:      models.TABLE,
+			wantPresent: []string{"s3cr3t", "pa55word"},
+			wantAbsent:  []string{MaskedValue},
+		},
+		{
+			name:        "hidden fields",
+			format:      models.TABLE,
+			hidden:      []string{"AWS_PASSWORD"},
+			wantPresent: []string{"AWS_SECRET_ACCESS_KEY", "s3cr3t"},
+			wantAbsent:  []string{"AWS_PASSWORD", "pa55word"},
+		},
+		{
+			name:        "hidden and masked",
+			format:      models.JSON,
+			masked:      secrets,
+			hidden:      []string{"AWS_SECRET_ACCESS_KEY"},
+			wantPresent: []string{`"AWS_PASSWORD": "` + MaskedValue + `"`},
+			wantAbsent:  []string{"AWS_SECRET_ACCESS_KEY", "s3cr3t", "pa55word"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := FprintStdOut(&out, data, tt.format, PrintOptions{Masked: tt.masked}, tt.hidden...); err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.wantPresent {
+				if !strings.Contains(out.String(), s) {
+					t.Errorf("%q missing from\n%s", s, out.String())
+				}
+			}
+			for _, s := range tt.wantAbsent {
+				if strings.Contains(out.String(), s) {
+					t.Errorf("%q printed in\n%s", s, out.String())
+				}
+			}
+		})
+	}
+}
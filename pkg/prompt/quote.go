@@ -0,0 +1,83 @@
+package prompt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+func sortedKeys(data map[string]string) []string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Single quotes keep the value literal; values with quotes or newlines fall back to escaped double quotes,
+// where $ and ` are escaped too so neither dotenv interpolation nor a shell sourcing the file expands them.
+func quoteDotenv(s string) string {
+	if !strings.ContainsAny(s, "'\n\r") {
+		return "'" + s + "'"
+	}
+
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`", "\n", `\n`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// Inside fish single quotes only the backslash and the quote itself are special.
+func quoteFish(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(s) + "'"
+}
+
+// PowerShell single-quoted strings are verbatim except for a doubled quote.
+func quotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// `set "KEY=value"` protects the value from & | < > ^; percent signs still expand in batch files.
+func quoteCmd(key, value string) (string, error) {
+	if strings.ContainsAny(value, "\n\r") {
+		return "", fmt.Errorf("%s contains a line break, which cmd cannot set", key)
+	}
+
+	return fmt.Sprintf(`set "%s=%s"`, key, strings.ReplaceAll(value, "%", "%%")), nil
+}
+
+// HCL strings treat ${ and %{ as template sequences, so they are escaped along with the usual characters.
+func quoteHCL(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", "$${", "%{", "%%{")
+	return `"` + r.Replace(s) + `"`
+}
+
+// Converts ENV_STYLE and camelCase keys to the snake_case Terraform uses for variable names.
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ' || r == '.':
+			b.WriteRune('_')
+		case unicode.IsUpper(r):
+			// Start a new word at a lower-to-upper boundary and before the last capital of an acronym
+			if i > 0 && runes[i-1] != '_' && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// Quotes s for POSIX shells: single quotes keep everything literal, a quote is closed, escaped and reopened.
+func QuoteShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
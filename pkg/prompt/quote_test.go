@@ -0,0 +1,70 @@
+package prompt
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		name  string
+		quote func(string) string
+		in    string
+		want  string
+	}{
+		{"dotenv plain", quoteDotenv, `abc$HOME\x`, `'abc$HOME\x'`},
+		{"dotenv quote", quoteDotenv, `it's $HOME`, `"it's \$HOME"`},
+		{"dotenv newline", quoteDotenv, "a\nb\r\"c\"", `"a\nb\r\"c\""`},
+		{"dotenv backslash and backtick", quoteDotenv, "'\\`id`${X}", `"'\\` + "\\`id\\`" + `\${X}"`},
+		{"fish", quoteFish, `it's a \ path $x`, `'it\'s a \\ path $x'`},
+		{"powershell", quotePowerShell, `it's $env:X`, `'it''s $env:X'`},
+		{"hcl", quoteHCL, "${var} %{if} \"q\"\t\\\n", `"$${var} %%{if} \"q\"\t\\\n"`},
+		{"shell", QuoteShell, `it's $HOME`, `'it'\''s $HOME'`},
+		{"shell empty", QuoteShell, "", `''`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quote(tt.in); got != tt.want {
+				t.Errorf("quote(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteCmd(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "a&b|c", want: `set "KEY=a&b|c"`},
+		{value: "100%", want: `set "KEY=100%%"`},
+		{value: "a\nb", wantErr: true},
+		{value: "a\rb", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := quoteCmd("KEY", tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("quoteCmd(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("quoteCmd(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"AWS_ACCESS_KEY_ID": "aws_access_key_id",
+		"clientId":          "client_id",
+		"ARMClientID":       "arm_client_id",
+		"subscription-id":   "subscription_id",
+		"key2Value":         "key2_value",
+		"already_snake":     "already_snake",
+	}
+
+	for in, want := range tests {
+		if got := toSnakeCase(in); got != want {
+			t.Errorf("toSnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package prompt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const DefaultSecretName = "bear-sandbox"
+
+const minMaskLength = 4
+
+// Metadata of the Kubernetes Secret rendered by the k8s-secret format.
+type KubernetesSecretOptions struct {
+	Name      string
+	Namespace string
+	Labels    map[string]string
+}
+
+func yamlString(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
+
+// A mapping node keeps the key order, which marshalling a map would not.
+func yamlMapping(data map[string]string, keys []string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, k := range keys {
+		node.Content = append(node.Content, yamlString(k), yamlString(data[k]))
+	}
+	return node
+}
+
+func FprintKubernetesSecret(w io.Writer, data map[string]string, keys []string, opts KubernetesSecretOptions) error {
+	name := opts.Name
+	if name == "" {
+		name = DefaultSecretName
+	}
+
+	metadata := yamlMapping(map[string]string{"name": name}, []string{"name"})
+	if opts.Namespace != "" {
+		metadata.Content = append(metadata.Content, yamlString("namespace"), yamlString(opts.Namespace))
+	}
+	if len(opts.Labels) > 0 {
+		metadata.Content = append(metadata.Content, yamlString("labels"), yamlMapping(opts.Labels, sortedKeys(opts.Labels)))
+	}
+
+	manifest := yamlMapping(map[string]string{"apiVersion": "v1", "kind": "Secret"}, []string{"apiVersion", "kind"})
+	manifest.Content = append(manifest.Content,
+		yamlString("metadata"), metadata,
+		yamlString("type"), yamlString("Opaque"),
+		yamlString("stringData"), yamlMapping(data, keys),
+	)
+
+	b, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(w, string(b))
+	return nil
+}
+
+// Masks the secrets in the job log and writes the variables in the syntax of the $GITHUB_ENV file.
+// Each line of a multi-line secret is masked on its own because the runner masks line by line;
+// lines as short as a lone brace are skipped, masking them would blank out half the log.
+func FprintGitHubActions(log io.Writer, env io.Writer, data map[string]string, keys []string, secrets []string) error {
+	escape := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	for _, k := range keys {
+		if !slices.Contains(secrets, k) {
+			continue
+		}
+		for _, line := range strings.Split(data[k], "\n") {
+			if line = strings.TrimSpace(line); len(line) >= minMaskLength {
+				fmt.Fprintf(log, "::add-mask::%s\n", escape.Replace(line))
+			}
+		}
+	}
+
+	for _, k := range keys {
+		value := data[k]
+		if !strings.ContainsAny(value, "\r\n") {
+			fmt.Fprintf(env, "%s=%s\n", k, value)
+			continue
+		}
+
+		delimiter, err := heredocDelimiter()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(env, "%s<<%s\n%s\n%s\n", k, delimiter, value, delimiter)
+	}
+	return nil
+}
+
+// A random delimiter cannot be forged by a value that happens to contain it.
+func heredocDelimiter() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "ghadelimiter_" + hex.EncodeToString(b), nil
+}
+
+// Prints ##vso[task.setvariable] logging commands, marking the secrets so the agent masks them.
+func FprintAzurePipelines(w io.Writer, data map[string]string, keys []string, secrets []string) error {
+	escape := strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A")
+	for _, k := range keys {
+		properties := "variable=" + k
+		if slices.Contains(secrets, k) {
+			properties += ";issecret=true"
+		}
+		fmt.Fprintf(w, "##vso[task.setvariable %s]%s\n", properties, escape.Replace(data[k]))
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package prompt
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestFprintGitHubActions(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		keys    []string
+		secrets []string
+		wantLog string
+		wantEnv string
+	}{
+		{
+			name:    "plain values",
+			data:    map[string]string{"A": "1", "SECRET": "s3cr3t%x"},
+			keys:    []string{"SECRET", "A"},
+			secrets: []string{"SECRET"},
+			wantLog: "::add-mask::s3cr3t%25x\n",
+			wantEnv: "SECRET=s3cr3t%x\nA=1\n",
+		},
+		{
+			name:    "multi-line secret",
+			data:    map[string]string{"KEY": "{\r\n  \"private_key\": \"abcdef\"\n}"},
+			keys:    []string{"KEY"},
+			secrets: []string{"KEY"},
+			wantLog: "::add-mask::\"private_key\": \"abcdef\"\n",
+			wantEnv: "KEY<<D\n{\r\n  \"private_key\": \"abcdef\"\n}\nD\n",
+		},
+		{
+			name:    "short secret lines are not masked",
+			data:    map[string]string{"PIN": "abc"},
+			keys:    []string{"PIN"},
+			secrets: []string{"PIN"},
+			wantEnv: "PIN=abc\n",
+		},
+		{
+			name:    "keys not listed are left out",
+			data:    map[string]string{"A": "1", "B": "2"},
+			keys:    []string{"B"},
+			wantEnv: "B=2\n",
+		},
+	}
+
+	delimiter := regexp.MustCompile(`ghadelimiter_[0-9a-f]{32}`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log, env strings.Builder
+			if err := FprintGitHubActions(&log, &env, tt.data, tt.keys, tt.secrets); err != nil {
+				t.Fatal(err)
+			}
+
+			if log.String() != tt.wantLog {
+				t.Errorf("log = %q, want %q", log.String(), tt.wantLog)
+			}
+
+			// The delimiter is random, so it must be the same at both ends of the heredoc and is then normalised
+			delimiters := delimiter.FindAllString(env.String(), -1)
+			if len(delimiters) == 2 && delimiters[0] != delimiters[1] {
+				t.Errorf("heredoc opened with %s and closed with %s", delimiters[0], delimiters[1])
+			}
+			if got := delimiter.ReplaceAllString(env.String(), "D"); got != tt.wantEnv {
+				t.Errorf("env = %q, want %q", got, tt.wantEnv)
+			}
+		})
+	}
+}
+
+func TestHeredocDelimiterIsRandom(t *testing.T) {
+	a, err := heredocDelimiter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := heredocDelimiter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Errorf("two delimiters are both %s", a)
+	}
+}
+
+func TestFprintAzurePipelines(t *testing.T) {
+	data := map[string]string{
+		"A":      "plain",
+		"SECRET": "100%\r\nline2",
+		"EMPTY":  "",
+	}
+
+	var out strings.Builder
+	if err := FprintAzurePipelines(&out, data, []string{"SECRET", "A", "EMPTY"}, []string{"SECRET"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "##vso[task.setvariable variable=SECRET;issecret=true]100%AZP25%0D%0Aline2\n" +
+		"##vso[task.setvariable variable=A]plain\n" +
+		"##vso[task.setvariable variable=EMPTY]\n"
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestFprintKubernetesSecret(t *testing.T) {
+	data := map[string]string{"B": "2", "A": "multi\nline"}
+
+	var out strings.Builder
+	opts := KubernetesSecretOptions{Namespace: "ci", Labels: map[string]string{"team": "platform", "app": "bear"}}
+	if err := FprintKubernetesSecret(&out, data, []string{"B", "A"}, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `apiVersion: v1
+kind: Secret
+metadata:
+    name: bear-sandbox
+    namespace: ci
+    labels:
+        app: bear
+        team: platform
+type: Opaque
+stringData:
+    B: "2"
+    A: |-
+        multi
+        line
+`
+	if out.String() != want {
+		t.Errorf("manifest =\n%s\nwant\n%s", out.String(), want)
+	}
+}